@@ -35,8 +35,18 @@ func Run(log *zap.SugaredLogger, cfg *config.Config) {
 		log.Infof("External CarInfo api running on: %v", cfg.ExternalCarsApi.URL)
 	}()
 
+	router := srv.InitRoutes()
+
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	workersDone := make(chan struct{})
+
+	go func() {
+		defer close(workersDone)
+		srv.RunWorkers(workersCtx)
+	}()
+
 	go func() {
-		if err := srv.Run(srv.InitRoutes()); err != nil {
+		if err := srv.Run(router); err != nil {
 			log.Fatalf("error while start server: %v", err)
 		}
 	}()
@@ -49,4 +59,7 @@ func Run(log *zap.SugaredLogger, cfg *config.Config) {
 		log.Infof("Error occured on server shutting down: %v", err)
 	}
 
+	stopWorkers()
+	<-workersDone
+
 }
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// extendWriteDeadline replaces the server write timeout of the request with timeout from now.
+// Writers that have no deadline, like recorders in tests, are left as they are.
+func extendWriteDeadline(rc *http.ResponseController, timeout time.Duration) error {
+	err := rc.SetWriteDeadline(time.Now().Add(timeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+
+	return err
+}
+
+// extendReadDeadline replaces the server read timeout of the request with timeout from now.
+func extendReadDeadline(rc *http.ResponseController, timeout time.Duration) error {
+	err := rc.SetReadDeadline(time.Now().Add(timeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+
+	return err
+}
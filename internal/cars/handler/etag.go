@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cacheControlKey = "cacheControl"
+
+// CacheControl sets the Cache-Control header successful reads of the route are sent with.
+// An empty value sends none.
+func CacheControl(value string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(cacheControlKey, value)
+	}
+}
+
+// versionETag is the entity tag of a car. The version changes with every change of the car
+// and its owner, so it is a strong tag of the car. Versions of different cars are alike, a plate
+// registered again starts at version 1 too, so the tag holds the id of the car as well.
+func versionETag(carID, version int) string {
+	return fmt.Sprintf(`"%d-%d"`, carID, version)
+}
+
+// contentETag is a strong entity tag computed from a response body.
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// setETag exposes the version of a car as its entity tag.
+func setETag(c *gin.Context, carID, version int) {
+	c.Header("ETag", versionETag(carID, version))
+}
+
+// writeCacheable writes body with its validators, or answers 304 when the copy of the client
+// is still fresh. A zero modified sends no Last-Modified.
+func writeCacheable(c *gin.Context, etag string, modified time.Time, body []byte) {
+	if value := c.GetString(cacheControlKey); value != "" {
+		c.Header("Cache-Control", value)
+	}
+
+	c.Header("ETag", etag)
+
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, modified) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// notModified evaluates If-None-Match, and If-Modified-Since only without it. Tags are compared
+// weakly and dates with seconds precision of the header.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if values := r.Header.Values("If-None-Match"); len(values) > 0 {
+		for _, tag := range strings.Split(strings.Join(values, ","), ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if modified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// ifMatchVersion returns the version of the car If-Match requires, nil when any version will do.
+// ok is false when the request was answered already.
+func ifMatchVersion(c *gin.Context, carID int) (version *int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	if strings.Contains(header, ",") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "If-Match with several entity tags is not supported",
+		})
+		return nil, false
+	}
+
+	// weak tags never match in If-Match, and tags that are not versions of the car belong to something else
+	tag, quoted := strings.CutPrefix(header, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+
+	if !quoted || !closed {
+		if strings.HasPrefix(header, `W/"`) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"message": "precondition failed",
+			})
+			return nil, false
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "If-Match must be an entity tag",
+		})
+		return nil, false
+	}
+
+	id, tagVersion, _ := strings.Cut(tag, "-")
+
+	v, err := strconv.Atoi(tagVersion)
+	if err != nil || id != strconv.Itoa(carID) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"message": "precondition failed",
+		})
+		return nil, false
+	}
+
+	return &v, true
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+// eventsTimeout replaces server timeouts of event streams after every write. It must exceed
+// the interval of pings, so that idle streams stay open.
+const eventsTimeout = time.Minute
+
+// StreamCarEvents godoc
+// @Summary Stream car changes
+// @Description Stream created, updated and deleted cars as server-sent events, the data of an event is the entry of the car history.
+// @Description Filtered streams also get events of cars that stopped matching the filters. After reconnecting with the id
+// @Description of the last event in the Last-Event-ID header or last_event_id query param, missed events are sent first
+// @Tags cars
+// @Produce  text/event-stream
+// @Param   mark query string false "Car mark"
+// @Param   owner_id query int false "Owner id"
+// @Param   last_event_id query string false "Resume after this event"
+// @Param   Last-Event-ID header string false "Resume after this event, takes precedence over last_event_id"
+// @Success 200 {object} models.CarEvent
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /cars/events [get]
+func (h *Handler) StreamCarEvents(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.StreamCarEvents")
+	defer span.End()
+
+	var input domain.CarEventsRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		input.LastEventID = header
+	}
+
+	rc := http.NewResponseController(c.Writer)
+
+	// the stream starts with the first write, so that errors before it are still reported with status
+	started := false
+
+	write := func(frame string) error {
+		if !started {
+			started = true
+
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+		}
+
+		// the read deadline matters too, the server cancels the request once it passes
+		if err := extendReadDeadline(rc, eventsTimeout); err != nil {
+			return err
+		}
+
+		if err := extendWriteDeadline(rc, eventsTimeout); err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(c.Writer, frame); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	err := h.service.StreamCarEvents(ctx, input, func(event models.CarEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		return write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.Position, event.Type, data))
+	}, func() error {
+		return write(": ping\n\n")
+	})
+
+	if err != nil && !started {
+		h.log.Infof("error while streaming car events: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	if err != nil {
+		h.log.Infof("stream of car events ended: %v", err)
+	}
+}
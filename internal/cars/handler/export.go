@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	// exportFlushRows is the number of rows written between flushes of an export.
+	exportFlushRows = 500
+	// exportWriteTimeout is how long one batch of rows can take to be written,
+	// it replaces the server write timeout that would cut long exports off.
+	exportWriteTimeout = 30 * time.Second
+)
+
+var carCSVHeader = []string{
+	"id", "regNum", "mark", "model", "year", "created_at", "deleted_at",
+	"owner_id", "owner_name", "owner_surname", "owner_patronymic",
+}
+
+// carEncoder writes exported cars in one of export formats.
+type carEncoder interface {
+	ContentType() string
+	Begin() error
+	Encode(car models.Car) error
+	Flush() error
+}
+
+func newCarEncoder(format string, w io.Writer) carEncoder {
+	if format == domain.ExportCSV {
+		return &csvCarEncoder{w: csv.NewWriter(w)}
+	}
+
+	return &ndjsonCarEncoder{enc: json.NewEncoder(w)}
+}
+
+type csvCarEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvCarEncoder) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e *csvCarEncoder) Begin() error {
+	return e.w.Write(carCSVHeader)
+}
+
+func (e *csvCarEncoder) Encode(car models.Car) error {
+	year := ""
+	if car.Year != nil {
+		year = strconv.Itoa(*car.Year)
+	}
+
+	deletedAt := ""
+	if car.DeletedAt != nil {
+		deletedAt = car.DeletedAt.Format(time.RFC3339)
+	}
+
+	return e.w.Write([]string{
+		strconv.Itoa(car.ID), car.RegNum, car.Mark, car.Model, year,
+		car.CreatedAt.Format(time.RFC3339), deletedAt,
+		strconv.Itoa(car.Owner.ID), car.Owner.Name, car.Owner.Surname, car.Owner.Patronymic,
+	})
+}
+
+func (e *csvCarEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonCarEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonCarEncoder) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (e *ndjsonCarEncoder) Begin() error {
+	return nil
+}
+
+func (e *ndjsonCarEncoder) Encode(car models.Car) error {
+	return e.enc.Encode(car)
+}
+
+func (e *ndjsonCarEncoder) Flush() error {
+	return nil
+}
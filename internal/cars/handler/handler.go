@@ -2,6 +2,9 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/Verce11o/effective-mobile-test/internal/domain"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/request"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
@@ -9,37 +12,66 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+const mergePatchContentType = "application/merge-patch+json"
+
 //go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Service
 type Service interface {
-	CreateCar(ctx context.Context, input domain.CreateCarsRequest) error
+	CreateCar(ctx context.Context, input domain.CreateCarsRequest) (models.CreateCarsResult, error)
 	GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error)
-	UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest) error
-	DeleteCar(ctx context.Context, carID int) error
+	SearchCars(ctx context.Context, input domain.SearchCarsRequest) (models.CarList, error)
+	GetCarStats(ctx context.Context, input domain.GetCarStatsRequest) (models.CarStats, error)
+	ExportCars(ctx context.Context, input domain.GetCarsRequest, fn func(car models.Car) error) error
+	ImportCars(ctx context.Context, file io.Reader) (models.ImportResult, error)
+	GetCar(ctx context.Context, carID int) (models.Car, error)
+	GetCarByRegNum(ctx context.Context, regNum string) (models.Car, error)
+	UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest, version *int) (int, error)
+	PatchCar(ctx context.Context, carID int, patch domain.CarPatch, version *int) (int, error)
+	DeleteCar(ctx context.Context, carID int, version *int) error
+	RestoreCar(ctx context.Context, carID int) error
+	PurgeCars(ctx context.Context) (int, error)
+	EvictCarInfo(ctx context.Context, regNum string) error
+	TransferCar(ctx context.Context, carID int, input domain.TransferCarRequest) error
+	GetCarOwners(ctx context.Context, carID int, input domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error)
+	GetCarHistory(ctx context.Context, carID int, input domain.GetCarHistoryRequest) (models.AuditList, error)
+	StreamCarEvents(ctx context.Context, input domain.CarEventsRequest, send func(event models.CarEvent) error, ping func() error) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=JobService
+type JobService interface {
+	EnqueueCreateCars(ctx context.Context, input domain.CreateCarsRequest) (models.Job, error)
 }
 
 type Handler struct {
 	log     *zap.SugaredLogger
 	service Service
+	jobs    JobService
 	tracer  trace.Tracer
 }
 
-func NewHandler(log *zap.SugaredLogger, service Service, tracer trace.Tracer) *Handler {
-	return &Handler{log: log, service: service, tracer: tracer}
+func NewHandler(log *zap.SugaredLogger, service Service, jobs JobService, tracer trace.Tracer) *Handler {
+	return &Handler{log: log, service: service, jobs: jobs, tracer: tracer}
 }
 
 // CreateCars godoc
 // @Summary Create new cars
-// @Description Create new cars with provided regnums
+// @Description Create new cars with provided regnums. onConflict controls existing plates: fail (default), skip or return.
+// @Description With partial every plate gets its own status and 207 is returned if some of them were not created
+// @Description With async a job is enqueued and 202 with the job is returned, its progress is available at /jobs/{id}
 // @Tags cars
 // @Accept  json
 // @Produce  json
 // @Param   cars body domain.CreateCarsRequest true "Create Cars Request"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} models.CreateCarsResult
+// @Success 202 {object} models.Job
+// @Success 207 {object} models.CreateCarsResult
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]any
 // @Failure 500 {object} map[string]string
 // @Router /cars [post]
 func (h *Handler) CreateCar(c *gin.Context) {
@@ -55,7 +87,19 @@ func (h *Handler) CreateCar(c *gin.Context) {
 		return
 	}
 
-	err := h.service.CreateCar(ctx, input)
+	if input.Async {
+		job, err := h.jobs.EnqueueCreateCars(ctx, input)
+		if err != nil {
+			h.log.Infof("error while enqueueing cars: %v", err)
+			response.WithHTTPError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	result, err := h.service.CreateCar(ctx, input)
 	if err != nil {
 
 		h.log.Infof("error while creating car: %v", err)
@@ -63,9 +107,111 @@ func (h *Handler) CreateCar(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "success",
-	})
+	status := http.StatusOK
+	if input.Partial && !allCreated(result.Results) {
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, result)
+}
+
+// ImportCars godoc
+// @Summary Import cars
+// @Description Create cars from an uploaded csv file with regNum, mark, model, year, owner_name, owner_surname and owner_patronymic columns.
+// @Description A row with only regNum is looked up in the external api, other rows must have mark, model, owner_name and owner_surname.
+// @Description Valid rows are created, 207 is returned if some rows were rejected. With report=csv rejected rows are returned as a csv file
+// @Description If the import stops after some rows were created, e.g. on a broken line, the report is truncated: it has truncated set and rows after the last counted one were not read
+// @Tags cars
+// @Accept  multipart/form-data
+// @Produce  json
+// @Produce  text/csv
+// @Param   file formData file true "CSV file"
+// @Param   report query string false "json (default) or csv"
+// @Success 200 {object} models.ImportResult
+// @Success 207 {object} models.ImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/import [post]
+func (h *Handler) ImportCars(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.ImportCars")
+	defer span.End()
+
+	var input domain.ImportCarsRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	rc := http.NewResponseController(c.Writer)
+
+	if err := extendReadDeadline(rc, importTimeout); err != nil {
+		h.log.Infof("cannot extend read deadline: %v", err)
+	}
+
+	if err := extendWriteDeadline(rc, importTimeout); err != nil {
+		h.log.Infof("cannot extend write deadline: %v", err)
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, importMaxBytes)
+
+	header, err := c.FormFile("file")
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"message": "file is too large",
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "file is required",
+		})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.log.Infof("error while opening import file: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	defer file.Close()
+
+	result, err := h.service.ImportCars(ctx, file)
+	if err != nil {
+		h.log.Infof("error while importing cars: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if result.Rejected > 0 || result.Truncated {
+		status = http.StatusMultiStatus
+	}
+
+	if input.Report != domain.ImportReportCSV {
+		c.JSON(status, result)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="import-errors.csv"`)
+	c.Header("X-Import-Rows", strconv.Itoa(result.Rows))
+	c.Header("X-Import-Created", strconv.Itoa(result.Created))
+	c.Header("X-Import-Rejected", strconv.Itoa(result.Rejected))
+	c.Header("X-Import-Truncated", strconv.FormatBool(result.Truncated))
+	c.Status(status)
+
+	if err = writeImportReport(c.Writer, result); err != nil {
+		h.log.Infof("error while writing import report: %v", err)
+	}
 }
 
 // GetCars godoc
@@ -79,8 +225,25 @@ func (h *Handler) CreateCar(c *gin.Context) {
 // @Param   year query int false "Car year"
 // @Param   regNum query string false "Car regnum"
 // @Param   cursor query string false "Cursor"
+// @Param   limit query int false "Page size"
 // @Param   owner_id query int false "Owner id"
+// @Param   owner_name query string false "Owner name"
+// @Param   owner_surname query string false "Owner surname"
+// @Param   owner_patronymic query string false "Owner patronymic"
+// @Param   owner_match query string false "How owner name filters are matched: exact (default) or prefix"
+// @Param   owner_ignore_case query bool false "Match owner name filters ignoring case"
+// @Param   include_deleted query bool false "Include deleted cars"
+// @Param   include_total query bool false "Count all cars matching the filters"
+// @Param   total_mode query string false "exact (default) or estimate, which is fast but approximate"
+// @Param   sort query string false "Sort fields: id, regNum, mark, model, year, created_at, owner.name, owner.surname. Prefix with - for descending order, e.g. -year,mark"
+// @Param   fields query string false "Car fields to return: id, regNum, mark, model, year, created_at, updated_at, deleted_at, version. All fields by default"
+// @Param   embed query string false "owner adds the owner to cars when fields are set"
+// @Param   If-None-Match header string false "ETag of a cached page, 304 is returned if it is still the same"
+// @Param   If-Modified-Since header string false "Date of a cached page, 304 is returned if no car was changed since"
 // @Success 200 {object} models.CarList
+// @Header  200 {string} ETag "Hash of the page"
+// @Header  200 {string} Last-Modified "Latest change of any car, not sent with deleted cars"
+// @Success 304
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -106,28 +269,324 @@ func (h *Handler) GetCars(c *gin.Context) {
 
 	}
 
+	body, err := json.Marshal(cars)
+	if err != nil {
+		h.log.Infof("error while encoding cars: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	var modified time.Time
+	if cars.LastModified != nil {
+		modified = *cars.LastModified
+	}
+
+	writeCacheable(c, contentETag(body), modified, body)
+}
+
+// SearchCars godoc
+// @Summary Search cars
+// @Description Search cars by plate, mark, model and owner name, surname or patronymic.
+// @Description Words are matched by prefix and misspelled words by similarity, most relevant cars go first
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   q query string true "Search query"
+// @Param   cursor query string false "Cursor"
+// @Param   limit query int false "Page size"
+// @Success 200 {object} models.CarList
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/search [get]
+func (h *Handler) SearchCars(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.SearchCars")
+	defer span.End()
+
+	var input domain.SearchCarsRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	cars, err := h.service.SearchCars(ctx, input)
+	if err != nil {
+		h.log.Infof("error while searching cars: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, cars)
+}
+
+// GetCarStats godoc
+// @Summary Get car stats
+// @Description Count cars by mark, model, year and decade, average car age, owners with most cars and cars added per day.
+// @Description Accepts the same filters as the car list
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   mark query string false "Car mark"
+// @Param   model query string false "Car model"
+// @Param   year query int false "Car year"
+// @Param   regNum query string false "Car regnum"
+// @Param   owner_id query int false "Owner id"
+// @Param   owner_name query string false "Owner name"
+// @Param   owner_surname query string false "Owner surname"
+// @Param   owner_patronymic query string false "Owner patronymic"
+// @Param   owner_match query string false "How owner name filters are matched: exact (default) or prefix"
+// @Param   owner_ignore_case query bool false "Match owner name filters ignoring case"
+// @Param   include_deleted query bool false "Include deleted cars"
+// @Param   from query string false "First day of cars added per day, YYYY-MM-DD. 29 days before to by default"
+// @Param   to query string false "Last day of cars added per day, YYYY-MM-DD. Today by default"
+// @Param   top_owners query int false "Number of owners with most cars, 10 by default"
+// @Success 200 {object} models.CarStats
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/stats [get]
+func (h *Handler) GetCarStats(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.GetCarStats")
+	defer span.End()
+
+	var input domain.GetCarStatsRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	stats, err := h.service.GetCarStats(ctx, input)
+	if err != nil {
+		h.log.Infof("error while getting car stats: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ExportCars godoc
+// @Summary Export cars
+// @Description Stream all cars matching the filters as csv or newline delimited json.
+// @Description An error after the first rows were sent ends the response early
+// @Tags cars
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Param   format query string true "csv or ndjson"
+// @Param   mark query string false "Car mark"
+// @Param   model query string false "Car model"
+// @Param   year query int false "Car year"
+// @Param   regNum query string false "Car regnum"
+// @Param   owner_id query int false "Owner id"
+// @Param   owner_name query string false "Owner name"
+// @Param   owner_surname query string false "Owner surname"
+// @Param   owner_patronymic query string false "Owner patronymic"
+// @Param   owner_match query string false "How owner name filters are matched: exact (default) or prefix"
+// @Param   owner_ignore_case query bool false "Match owner name filters ignoring case"
+// @Param   include_deleted query bool false "Include deleted cars"
+// @Param   sort query string false "Sort fields, see GET /cars"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/export [get]
+func (h *Handler) ExportCars(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.ExportCars")
+	defer span.End()
+
+	var input domain.ExportCarsRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	rc := http.NewResponseController(c.Writer)
+	encoder := newCarEncoder(input.Format, c.Writer)
+
+	// the response starts with the first car, so that errors of the query are still reported with status
+	started := false
+	start := func() error {
+		started = true
+
+		c.Header("Content-Type", encoder.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="cars.%s"`, input.Format))
+		c.Status(http.StatusOK)
+
+		return encoder.Begin()
+	}
+
+	// every flushed batch gets its own deadline instead of the server write timeout
+	flush := func() error {
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+
+		return extendWriteDeadline(rc, exportWriteTimeout)
+	}
+
+	rows := 0
+
+	err := h.service.ExportCars(ctx, input.GetCarsRequest, func(car models.Car) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+
+			if err := extendWriteDeadline(rc, exportWriteTimeout); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.Encode(car); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushRows == 0 {
+			return flush()
+		}
+
+		return nil
+	})
+
+	if err != nil && !started {
+		h.log.Infof("error while exporting cars: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	if err != nil {
+		h.log.Infof("export of cars was interrupted after %d rows: %v", rows, err)
+		return
+	}
+
+	if !started {
+		if err = start(); err != nil {
+			h.log.Infof("error while exporting cars: %v", err)
+			return
+		}
+	}
+
+	if err = encoder.Flush(); err != nil {
+		h.log.Infof("error while exporting cars: %v", err)
+	}
+}
+
+// GetCar godoc
+// @Summary Get car
+// @Description Get car by id
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Car ID"
+// @Param   If-None-Match header string false "ETag of a cached car, 304 is returned if it is still the same"
+// @Param   If-Modified-Since header string false "Date of a cached car, 304 is returned if it was not changed since"
+// @Success 200 {object} models.Car
+// @Header  200 {string} ETag "Id and version of the car"
+// @Header  200 {string} Last-Modified "Time of the last change of the car"
+// @Success 304
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/{id} [get]
+func (h *Handler) GetCar(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.GetCar")
+	defer span.End()
+
+	carID, ok := readCarID(c)
+	if !ok {
+		return
+	}
+
+	car, err := h.service.GetCar(ctx, carID)
+	if err != nil {
+		h.log.Infof("error while getting car %v: %v", carID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	h.writeCar(c, car)
+}
+
+// GetCarByRegNum godoc
+// @Summary Get car by regnum
+// @Description Get car by registration number
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   regNum path string true "Car regnum"
+// @Param   If-None-Match header string false "ETag of a cached car, 304 is returned if it is still the same"
+// @Param   If-Modified-Since header string false "Date of a cached car, 304 is returned if it was not changed since"
+// @Success 200 {object} models.Car
+// @Header  200 {string} ETag "Id and version of the car"
+// @Header  200 {string} Last-Modified "Time of the last change of the car"
+// @Success 304
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/by-regnum/{regNum} [get]
+func (h *Handler) GetCarByRegNum(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.GetCarByRegNum")
+	defer span.End()
+
+	regNum := c.Param("regNum")
+
+	car, err := h.service.GetCarByRegNum(ctx, regNum)
+	if err != nil {
+		h.log.Infof("error while getting car %v: %v", regNum, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	h.writeCar(c, car)
+}
 
+// writeCar answers a read of the car, its id and version are the ETag.
+func (h *Handler) writeCar(c *gin.Context, car models.Car) {
+	body, err := json.Marshal(car)
+	if err != nil {
+		h.log.Infof("error while encoding car %v: %v", car.ID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	writeCacheable(c, versionETag(car.ID, car.Version), car.UpdatedAt, body)
 }
 
 // UpdateCar godoc
-// @Summary Update car
-// @Description Update car by id
+// @Summary Replace car
+// @Description Replace all fields of car by id, a missing year clears it. The id can also be passed as a query param of PUT /cars
 // @Tags cars
 // @Accept  json
 // @Produce  json
-// @Param   id query int true "Car ID"
+// @Param   id path int true "Car ID"
 // @Param   car body domain.UpdateCarsRequest true "Update Car Request"
+// @Param   If-Match header string false "ETag of the car, it is only updated if it was not changed since"
 // @Success 200 {object} map[string]string
+// @Header  200 {string} ETag "Id and new version of the car"
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /cars [put]
+// @Failure 409 {object} map[string]any
+// @Failure 412 {object} map[string]string
+// @Router /cars/{id} [put]
 func (h *Handler) UpdateCar(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.UpdateCar")
 	defer span.End()
 
-	id := c.Query("id")
+	id := c.Param("id")
+	if id == "" {
+		id = c.Query("id")
+	}
 
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -152,35 +611,109 @@ func (h *Handler) UpdateCar(c *gin.Context) {
 		return
 	}
 
-	err = h.service.UpdateCar(ctx, carID, input)
+	version, ok := ifMatchVersion(c, carID)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.UpdateCar(ctx, carID, input, version)
 	if err != nil {
 		h.log.Infof("error while updating car %v: %v", carID, err)
 		response.WithHTTPError(c, err)
 		return
 	}
 
+	setETag(c, carID, updated)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "success",
 	})
 
 }
 
+// PatchCar godoc
+// @Summary Patch car
+// @Description Update car by id with a JSON merge patch: missing fields are left as they are and null clears a field.
+// @Description Only year can be cleared
+// @Tags cars
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param   id path int true "Car ID"
+// @Param   car body domain.CarPatch true "Merge patch of the car"
+// @Param   If-Match header string false "ETag of the car, it is only updated if it was not changed since"
+// @Success 200 {object} map[string]string
+// @Header  200 {string} ETag "Id and new version of the car"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]any
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/{id} [patch]
+func (h *Handler) PatchCar(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.PatchCar")
+	defer span.End()
+
+	carID, ok := readCarID(c)
+	if !ok {
+		return
+	}
+
+	if contentType := c.ContentType(); contentType != mergePatchContentType && contentType != gin.MIMEJSON {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"message": "content type must be " + mergePatchContentType,
+		})
+		return
+	}
+
+	var patch domain.CarPatch
+	if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	version, ok := ifMatchVersion(c, carID)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.PatchCar(ctx, carID, patch, version)
+	if err != nil {
+		h.log.Infof("error while patching car %v: %v", carID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	setETag(c, carID, updated)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
+
 // DeleteCar godoc
 // @Summary Delete car
-// @Description Delete car by ID
+// @Description Delete car by ID. The car is only marked as deleted and can be restored until it is purged.
+// @Description The id can also be passed as a query param of DELETE /cars
 // @Tags cars
 // @Accept  json
 // @Produce  json
-// @Param   id query int true "Car ID"
+// @Param   id path int true "Car ID"
+// @Param   If-Match header string false "ETag of the car, it is only deleted if it was not changed since"
 // @Success 200 {string} string "OK"
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /cars [delete]
+// @Router /cars/{id} [delete]
 func (h *Handler) DeleteCar(c *gin.Context) {
 	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.DeleteCar")
 	defer span.End()
 
-	id := c.Query("id")
+	id := c.Param("id")
+	if id == "" {
+		id = c.Query("id")
+	}
 
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -197,7 +730,12 @@ func (h *Handler) DeleteCar(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteCar(ctx, carID)
+	version, ok := ifMatchVersion(c, carID)
+	if !ok {
+		return
+	}
+
+	err = h.service.DeleteCar(ctx, carID, version)
 	if err != nil {
 		h.log.Infof("error while deleting car %v: %v", carID, err)
 		response.WithHTTPError(c, err)
@@ -208,3 +746,238 @@ func (h *Handler) DeleteCar(c *gin.Context) {
 		"message": "success",
 	})
 }
+
+// RestoreCar godoc
+// @Summary Restore car
+// @Description Restore deleted car by ID
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Car ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]string
+// @Router /cars/{id}/restore [post]
+func (h *Handler) RestoreCar(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.RestoreCar")
+	defer span.End()
+
+	carID, ok := readCarID(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.RestoreCar(ctx, carID)
+	if err != nil {
+		h.log.Infof("error while restoring car %v: %v", carID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
+
+// PurgeCars godoc
+// @Summary Purge deleted cars
+// @Description Permanently remove cars deleted longer than the retention period ago
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} map[string]int
+// @Failure 500 {object} map[string]string
+// @Router /cars/purge [post]
+func (h *Handler) PurgeCars(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.PurgeCars")
+	defer span.End()
+
+	purged, err := h.service.PurgeCars(ctx)
+	if err != nil {
+		h.log.Infof("error while purging cars: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"purged": purged,
+	})
+}
+
+// EvictCarInfo godoc
+// @Summary Evict car info
+// @Description Remove cached external api answer for the regnum, next lookup goes to the external api
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   regNum path string true "Car regnum"
+// @Success 200 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/info-cache/{regNum} [delete]
+func (h *Handler) EvictCarInfo(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.EvictCarInfo")
+	defer span.End()
+
+	regNum := c.Param("regNum")
+
+	err := h.service.EvictCarInfo(ctx, regNum)
+	if err != nil {
+		h.log.Infof("error while evicting car info %v: %v", regNum, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
+
+// TransferCar godoc
+// @Summary Transfer car
+// @Description Transfer car to another owner and record the change in ownership history
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Car ID"
+// @Param   transfer body domain.TransferCarRequest true "Transfer Car Request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/{id}/transfer [post]
+func (h *Handler) TransferCar(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.TransferCar")
+	defer span.End()
+
+	carID, ok := readCarID(c)
+	if !ok {
+		return
+	}
+
+	var input domain.TransferCarRequest
+	if err := request.Read(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err,
+		})
+		return
+	}
+
+	err := h.service.TransferCar(ctx, carID, input)
+	if err != nil {
+		h.log.Infof("error while transferring car %v: %v", carID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
+
+// GetCarOwners godoc
+// @Summary Get car owners
+// @Description Get ownership chain of the car. With "at" only the owner on that date is returned
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Car ID"
+// @Param   at query string false "Date in RFC3339 format"
+// @Success 200 {array} models.OwnershipRecord
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/{id}/owners [get]
+func (h *Handler) GetCarOwners(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.GetCarOwners")
+	defer span.End()
+
+	carID, ok := readCarID(c)
+	if !ok {
+		return
+	}
+
+	var input domain.GetCarOwnersRequest
+
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	records, err := h.service.GetCarOwners(ctx, carID, input)
+	if err != nil {
+		h.log.Infof("error while getting car %v owners: %v", carID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// GetCarHistory godoc
+// @Summary Get car history
+// @Description Get changes of the car, the latest first. Every entry holds who made the change, if the request named them
+// @Description in the X-Actor header, and old and new values of changed fields. History of deleted cars is kept
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Car ID"
+// @Param   cursor query string false "Cursor"
+// @Param   limit query int false "Page size"
+// @Success 200 {object} models.AuditList
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cars/{id}/history [get]
+func (h *Handler) GetCarHistory(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "carHandler.GetCarHistory")
+	defer span.End()
+
+	carID, ok := readCarID(c)
+	if !ok {
+		return
+	}
+
+	var input domain.GetCarHistoryRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	history, err := h.service.GetCarHistory(ctx, carID, input)
+	if err != nil {
+		h.log.Infof("error while getting car %v history: %v", carID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func readCarID(c *gin.Context) (int, bool) {
+	carID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "id must be integer",
+		})
+		return 0, false
+	}
+
+	return carID, true
+}
+
+func allCreated(results []models.PlateResult) bool {
+	for _, result := range results {
+		if result.Status != models.PlateStatusCreated {
+			return false
+		}
+	}
+
+	return true
+}
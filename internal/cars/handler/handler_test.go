@@ -2,18 +2,25 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/Verce11o/effective-mobile-test/internal/cars/handler/mocks"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_CreateCar(t *testing.T) {
@@ -44,6 +51,26 @@ func TestHandler_CreateCar(t *testing.T) {
 			},
 			statusCode: http.StatusBadRequest,
 		},
+		{
+			name: "invalid conflict mode",
+			args: args{
+				input: map[string]any{
+					"regNums":    []string{"E387IK307"},
+					"onConflict": "overwrite",
+				},
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "existing reg nums",
+			args: args{
+				input: map[string]any{
+					"regNums": []string{"E387IK307"},
+				},
+			},
+			statusCode: http.StatusConflict,
+			wantErr:    &response.ConflictError{RegNums: []string{"E387IK307"}},
+		},
 		{
 			name: "invalid reg nums",
 			args: args{
@@ -77,7 +104,60 @@ func TestHandler_CreateCar(t *testing.T) {
 				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
 			}
 
-			serviceMock.On("CreateCar", mock.Anything, mock.AnythingOfType("domain.CreateCarsRequest")).Return(tt.wantErr).Maybe()
+			serviceMock.On("CreateCar", mock.Anything, mock.AnythingOfType("domain.CreateCarsRequest")).Return(models.CreateCarsResult{}, tt.wantErr).Maybe()
+			h.CreateCar(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_CreateCarPartial(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    []models.PlateResult
+		statusCode int
+	}{
+		{
+			name:       "all created",
+			results:    []models.PlateResult{{RegNum: "E387IK307", Status: models.PlateStatusCreated, CarID: 1}},
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "some failed",
+			results: []models.PlateResult{
+				{RegNum: "E387IK307", Status: models.PlateStatusCreated, CarID: 1},
+				{RegNum: "A000AA000", Status: models.PlateStatusNotFound},
+			},
+			statusCode: http.StatusMultiStatus,
+		},
+	}
+
+	log := logger.NewMockLogger()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+
+		ctx.Request = &http.Request{
+			Method: http.MethodPost,
+			Header: make(http.Header),
+		}
+
+		MockJsonPost(ctx, map[string]any{
+			"regNums": []string{"E387IK307", "A000AA000"},
+			"partial": true,
+		})
+
+		serviceMock := mocks.NewService(t)
+
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				log:     log,
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			serviceMock.On("CreateCar", mock.Anything, mock.AnythingOfType("domain.CreateCarsRequest")).Return(models.CreateCarsResult{Results: tt.results}, nil).Once()
 			h.CreateCar(ctx)
 
 			assert.EqualValues(t, tt.statusCode, w.Code)
@@ -85,6 +165,496 @@ func TestHandler_CreateCar(t *testing.T) {
 	}
 }
 
+func TestHandler_CreateCarAsync(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+
+	ctx.Request = &http.Request{
+		Method: http.MethodPost,
+		Header: make(http.Header),
+	}
+
+	MockJsonPost(ctx, map[string]any{
+		"regNums": []string{"E387IK307", "A000AA000"},
+		"async":   true,
+	})
+
+	serviceMock := mocks.NewService(t)
+	jobsMock := mocks.NewJobService(t)
+
+	h := &Handler{
+		log:     logger.NewMockLogger(),
+		service: serviceMock,
+		jobs:    jobsMock,
+		tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+	}
+
+	jobsMock.On("EnqueueCreateCars", mock.Anything, mock.AnythingOfType("domain.CreateCarsRequest")).
+		Return(models.Job{ID: 1, Status: models.JobStatusQueued, Total: 2}, nil).Once()
+	h.CreateCar(ctx)
+
+	assert.EqualValues(t, http.StatusAccepted, w.Code)
+}
+
+func TestHandler_ExportCars(t *testing.T) {
+	createdAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	year2020, year2018 := 2020, 2018
+	cars := []models.Car{
+		{ID: 1, RegNum: "X123XX150", Mark: "Lada", Model: "Vesta", Year: &year2020, CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1,
+			Owner: models.People{ID: 2, Name: "Ivan", Surname: "Ivanov"}},
+		{ID: 3, RegNum: "A000AA000", Mark: "BMW", Model: "X5", Year: &year2018, CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1,
+			Owner: models.People{ID: 2, Name: "Ivan", Surname: "Ivanov"}},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		serviceErr  error
+		failAfter   int
+		statusCode  int
+		contentType string
+		wantBody    string
+	}{
+		{
+			name:        "csv",
+			query:       "format=csv&mark=Lada",
+			statusCode:  http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			wantBody: "id,regNum,mark,model,year,created_at,deleted_at,owner_id,owner_name,owner_surname,owner_patronymic\n" +
+				"1,X123XX150,Lada,Vesta,2020,2026-10-18T12:00:00Z,,2,Ivan,Ivanov,\n" +
+				"3,A000AA000,BMW,X5,2018,2026-10-18T12:00:00Z,,2,Ivan,Ivanov,\n",
+		},
+		{
+			name:        "ndjson",
+			query:       "format=ndjson",
+			statusCode:  http.StatusOK,
+			contentType: "application/x-ndjson",
+			wantBody: `{"id":1,"regNum":"X123XX150","mark":"Lada","model":"Vesta","year":2020,"created_at":"2026-10-18T12:00:00Z","updated_at":"2026-10-18T12:00:00Z","version":1,"owner":{"id":2,"name":"Ivan","surname":"Ivanov"}}` + "\n" +
+				`{"id":3,"regNum":"A000AA000","mark":"BMW","model":"X5","year":2018,"created_at":"2026-10-18T12:00:00Z","updated_at":"2026-10-18T12:00:00Z","version":1,"owner":{"id":2,"name":"Ivan","surname":"Ivanov"}}` + "\n",
+		},
+		{
+			name:       "unknown format",
+			query:      "format=xml",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "error before first row",
+			query:      "format=csv&sort=color",
+			serviceErr: response.ErrInvalidRequest,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:        "error after first row",
+			query:       "format=ndjson",
+			serviceErr:  errors.New("connection lost"),
+			failAfter:   1,
+			statusCode:  http.StatusOK,
+			contentType: "application/x-ndjson",
+			wantBody:    `{"id":1,"regNum":"X123XX150","mark":"Lada","model":"Vesta","year":2020,"created_at":"2026-10-18T12:00:00Z","updated_at":"2026-10-18T12:00:00Z","version":1,"owner":{"id":2,"name":"Ivan","surname":"Ivanov"}}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cars/export?"+tt.query, nil)
+
+			serviceMock := mocks.NewService(t)
+
+			h := &Handler{
+				log:     logger.NewMockLogger(),
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			if tt.statusCode == http.StatusOK || tt.serviceErr != nil {
+				serviceMock.On("ExportCars", mock.Anything, mock.AnythingOfType("domain.GetCarsRequest"), mock.Anything).
+					Return(func(_ context.Context, _ domain.GetCarsRequest, fn func(car models.Car) error) error {
+						for i, car := range cars {
+							if tt.serviceErr != nil && i == tt.failAfter {
+								return tt.serviceErr
+							}
+
+							if err := fn(car); err != nil {
+								return err
+							}
+						}
+
+						return nil
+					}).Once()
+			}
+
+			h.ExportCars(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+
+			if tt.contentType != "" {
+				assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ImportCars(t *testing.T) {
+	result := models.ImportResult{
+		Rows:     2,
+		Created:  1,
+		Rejected: 1,
+		Errors:   []models.ImportRowError{{Row: 3, RegNum: "A000AA000", Reason: "car already exists"}},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		file        bool
+		statusCode  int
+		contentType string
+		wantBody    string
+	}{
+		{
+			name:        "json report",
+			file:        true,
+			statusCode:  http.StatusMultiStatus,
+			contentType: "application/json; charset=utf-8",
+			wantBody:    `{"rows":2,"created":1,"rejected":1,"truncated":false,"errors":[{"row":3,"regNum":"A000AA000","reason":"car already exists"}]}`,
+		},
+		{
+			name:        "csv report",
+			query:       "?report=csv",
+			file:        true,
+			statusCode:  http.StatusMultiStatus,
+			contentType: "text/csv; charset=utf-8",
+			wantBody:    "row,regNum,reason\n3,A000AA000,car already exists\n",
+		},
+		{
+			name:       "no file",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown report",
+			query:      "?report=xml",
+			file:       true,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			form := multipart.NewWriter(body)
+
+			if tt.file {
+				part, err := form.CreateFormFile("file", "cars.csv")
+				require.NoError(t, err)
+
+				_, err = part.Write([]byte("regNum\nJ623FP555\nA000AA000\n"))
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, form.Close())
+
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/cars/import"+tt.query, body)
+			ctx.Request.Header.Set("Content-Type", form.FormDataContentType())
+
+			serviceMock := mocks.NewService(t)
+
+			h := &Handler{
+				log:     logger.NewMockLogger(),
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			if tt.contentType != "" {
+				serviceMock.On("ImportCars", mock.Anything, mock.Anything).Return(result, nil).Once()
+			}
+
+			h.ImportCars(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+
+			if tt.contentType != "" {
+				assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_PatchCar(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		serviceErr  error
+		statusCode  int
+	}{
+		{
+			name:        "merge patch",
+			contentType: "application/merge-patch+json",
+			body:        `{"year": null}`,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "plain json",
+			contentType: "application/json",
+			body:        `{"mark": "BMW"}`,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        `{"year": null}`,
+			statusCode:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "unknown field",
+			contentType: "application/merge-patch+json",
+			body:        `{"id": 2}`,
+			statusCode:  http.StatusBadRequest,
+		},
+		{
+			name:        "car not found",
+			contentType: "application/merge-patch+json",
+			body:        `{"year": 2018}`,
+			serviceErr:  response.ErrNotFound,
+			statusCode:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = httptest.NewRequest(http.MethodPatch, "/api/v1/cars/1", bytes.NewBufferString(tt.body))
+			ctx.Request.Header.Set("Content-Type", tt.contentType)
+			ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+
+			serviceMock := mocks.NewService(t)
+
+			h := &Handler{
+				log:     logger.NewMockLogger(),
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			if tt.statusCode == http.StatusOK || tt.serviceErr != nil {
+				serviceMock.On("PatchCar", mock.Anything, 1, mock.AnythingOfType("domain.CarPatch"), (*int)(nil)).Return(2, tt.serviceErr).Once()
+			}
+
+			h.PatchCar(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_GetCarConditional(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 18, 12, 0, 0, 500, time.UTC)
+	car := models.Car{ID: 1, RegNum: "X123XX150", UpdatedAt: updatedAt, Version: 3}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		statusCode int
+	}{
+		{name: "No validators", statusCode: http.StatusOK},
+		{name: "Same version", headers: map[string]string{"If-None-Match": `"1-3"`}, statusCode: http.StatusNotModified},
+		{name: "Weak same version", headers: map[string]string{"If-None-Match": `"1-1", W/"1-3"`}, statusCode: http.StatusNotModified},
+		{name: "Other version", headers: map[string]string{"If-None-Match": `"1-2"`}, statusCode: http.StatusOK},
+		{name: "Same version of another car", headers: map[string]string{"If-None-Match": `"2-3"`}, statusCode: http.StatusOK},
+		{name: "Not modified since", headers: map[string]string{"If-Modified-Since": "Sun, 18 Oct 2026 12:00:00 GMT"}, statusCode: http.StatusNotModified},
+		{name: "Modified since", headers: map[string]string{"If-Modified-Since": "Sun, 18 Oct 2026 11:59:59 GMT"}, statusCode: http.StatusOK},
+		{
+			name: "If-None-Match wins",
+			headers: map[string]string{
+				"If-None-Match":     `"1-2"`,
+				"If-Modified-Since": "Sun, 18 Oct 2026 12:00:00 GMT",
+			},
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cars/1", nil)
+			ctx.Params = gin.Params{{Key: "id", Value: "1"}}
+			for key, value := range tt.headers {
+				ctx.Request.Header.Set(key, value)
+			}
+
+			serviceMock := mocks.NewService(t)
+			serviceMock.On("GetCar", mock.Anything, 1).Return(car, nil).Once()
+
+			h := &Handler{
+				log:     logger.NewMockLogger(),
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			CacheControl("private, no-cache")(ctx)
+			h.GetCar(ctx)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, `"1-3"`, w.Header().Get("ETag"))
+			assert.Equal(t, "Sun, 18 Oct 2026 12:00:00 GMT", w.Header().Get("Last-Modified"))
+			assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+
+			if tt.statusCode == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetCarsConditional(t *testing.T) {
+	lastModified := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	list := models.CarList{LastModified: &lastModified, Cars: []models.Car{{ID: 1, RegNum: "X123XX150", Version: 1}}}
+
+	get := func(t *testing.T, list models.CarList, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cars", nil)
+		if ifNoneMatch != "" {
+			ctx.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		serviceMock := mocks.NewService(t)
+		serviceMock.On("GetCars", mock.Anything, mock.AnythingOfType("domain.GetCarsRequest")).Return(list, nil).Once()
+
+		h := &Handler{
+			log:     logger.NewMockLogger(),
+			service: serviceMock,
+			tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+		}
+
+		h.GetCars(ctx)
+
+		return w
+	}
+
+	first := get(t, list, "")
+	require.Equal(t, http.StatusOK, first.Code)
+
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "Sun, 18 Oct 2026 12:00:00 GMT", first.Header().Get("Last-Modified"))
+	assert.Empty(t, first.Header().Get("Cache-Control"))
+
+	assert.Equal(t, http.StatusNotModified, get(t, list, etag).Code)
+
+	list.Cars[0].Version = 2
+	changed := get(t, list, etag)
+
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+}
+
+func TestHandler_StreamCarEvents(t *testing.T) {
+	mark := "Lada"
+	event := models.CarEvent{
+		Type:       models.CarEventCreated,
+		AuditEntry: models.AuditEntry{ID: 7, CarID: 1, Operation: models.AuditCreate, CreatedAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		Position:   models.EventPosition{Tx: 750, ID: 7},
+		Mark:       &mark,
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		wantInput   domain.CarEventsRequest
+		serviceErr  error
+		statusCode  int
+		wantBody    string
+	}{
+		{
+			name:       "Stream",
+			query:      "?mark=Lada",
+			wantInput:  domain.CarEventsRequest{Mark: "Lada"},
+			statusCode: http.StatusOK,
+			wantBody: ": ping\n\n" +
+				"id: 750-7\nevent: created\ndata: " +
+				`{"type":"created","id":7,"car_id":1,"operation":"create","diff":null,"created_at":"2026-10-18T12:00:00Z","mark":"Lada"}` + "\n\n",
+		},
+		{
+			name:        "Last-Event-ID wins",
+			query:       "?last_event_id=700-3",
+			lastEventID: "740-5",
+			wantInput:   domain.CarEventsRequest{LastEventID: "740-5"},
+			statusCode:  http.StatusOK,
+			wantBody: ": ping\n\n" +
+				"id: 750-7\nevent: created\ndata: " +
+				`{"type":"created","id":7,"car_id":1,"operation":"create","diff":null,"created_at":"2026-10-18T12:00:00Z","mark":"Lada"}` + "\n\n",
+		},
+		{
+			name:        "Invalid Last-Event-ID",
+			lastEventID: "abc",
+			serviceErr:  response.ErrInvalidRequest,
+			statusCode:  http.StatusBadRequest,
+		},
+		{
+			name:       "Shutting down",
+			serviceErr: response.ErrUnavailable,
+			statusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cars/events"+tt.query, nil)
+			if tt.lastEventID != "" {
+				ctx.Request.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+
+			serviceMock := mocks.NewService(t)
+
+			h := &Handler{
+				log:     logger.NewMockLogger(),
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			if tt.statusCode == http.StatusOK {
+				serviceMock.On("StreamCarEvents", mock.Anything, tt.wantInput, mock.Anything, mock.Anything).
+					Return(func(_ context.Context, _ domain.CarEventsRequest, send func(models.CarEvent) error, ping func() error) error {
+						if err := ping(); err != nil {
+							return err
+						}
+
+						return send(event)
+					}).Once()
+			}
+
+			if tt.serviceErr != nil {
+				serviceMock.On("StreamCarEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.serviceErr).Once()
+			}
+
+			h.StreamCarEvents(ctx)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+
+			if tt.statusCode == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
 func MockJsonPost(c *gin.Context, body interface{}) {
 	c.Request.Method = "POST"
 	c.Request.Header.Set("Content-Type", "application/json")
@@ -96,3 +666,43 @@ func MockJsonPost(c *gin.Context, body interface{}) {
 
 	c.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
 }
+
+func TestIfMatchVersion(t *testing.T) {
+	version := 3
+
+	tests := []struct {
+		name         string
+		header       string
+		wantVersion  *int
+		wantOK       bool
+		expectedCode int
+	}{
+		{name: "No header", header: "", wantOK: true},
+		{name: "Any version", header: "*", wantOK: true},
+		{name: "Version", header: `"1-3"`, wantVersion: &version, wantOK: true},
+		{name: "Version of another car", header: `"2-3"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Version without car", header: `"3"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Weak tag", header: `W/"1-3"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Unknown tag", header: `"1-abc"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Not a tag", header: "1-3", expectedCode: http.StatusBadRequest},
+		{name: "Several tags", header: `"1-3", "1-4"`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPatch, "/cars/1", nil)
+			ctx.Request.Header.Set("If-Match", tt.header)
+
+			got, ok := ifMatchVersion(ctx, 1)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantVersion, got)
+
+			if !tt.wantOK {
+				assert.Equal(t, tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"encoding/csv"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	// importMaxBytes limits the size of an uploaded file.
+	importMaxBytes = 32 << 20
+	// importTimeout replaces server timeouts for imports, plates of big files take long to look up.
+	importTimeout = 10 * time.Minute
+)
+
+// writeImportReport writes rejected rows of result as csv.
+func writeImportReport(w io.Writer, result models.ImportResult) error {
+	report := csv.NewWriter(w)
+
+	if err := report.Write([]string{"row", "regNum", "reason"}); err != nil {
+		return err
+	}
+
+	for _, rowErr := range result.Errors {
+		if err := report.Write([]string{strconv.Itoa(rowErr.Row), rowErr.RegNum, rowErr.Reason}); err != nil {
+			return err
+		}
+	}
+
+	report.Flush()
+	return report.Error()
+}
@@ -7,6 +7,8 @@ import (
 
 	domain "github.com/Verce11o/effective-mobile-test/internal/domain"
 
+	io "io"
+
 	mock "github.com/stretchr/testify/mock"
 
 	models "github.com/Verce11o/effective-mobile-test/internal/models"
@@ -18,16 +20,44 @@ type Service struct {
 }
 
 // CreateCar provides a mock function with given fields: ctx, input
-func (_m *Service) CreateCar(ctx context.Context, input domain.CreateCarsRequest) error {
+func (_m *Service) CreateCar(ctx context.Context, input domain.CreateCarsRequest) (models.CreateCarsResult, error) {
 	ret := _m.Called(ctx, input)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateCar")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCarsRequest) error); ok {
+	var r0 models.CreateCarsResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCarsRequest) (models.CreateCarsResult, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCarsRequest) models.CreateCarsResult); ok {
 		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.CreateCarsResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateCarsRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCar provides a mock function with given fields: ctx, carID, version
+func (_m *Service) DeleteCar(ctx context.Context, carID int, version *int) error {
+	ret := _m.Called(ctx, carID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int) error); ok {
+		r0 = rf(ctx, carID, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -35,17 +65,17 @@ func (_m *Service) CreateCar(ctx context.Context, input domain.CreateCarsRequest
 	return r0
 }
 
-// DeleteCar provides a mock function with given fields: ctx, carID
-func (_m *Service) DeleteCar(ctx context.Context, carID int) error {
-	ret := _m.Called(ctx, carID)
+// EvictCarInfo provides a mock function with given fields: ctx, regNum
+func (_m *Service) EvictCarInfo(ctx context.Context, regNum string) error {
+	ret := _m.Called(ctx, regNum)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteCar")
+		panic("no return value specified for EvictCarInfo")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, carID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, regNum)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -53,6 +83,166 @@ func (_m *Service) DeleteCar(ctx context.Context, carID int) error {
 	return r0
 }
 
+// ExportCars provides a mock function with given fields: ctx, input, fn
+func (_m *Service) ExportCars(ctx context.Context, input domain.GetCarsRequest, fn func(models.Car) error) error {
+	ret := _m.Called(ctx, input, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportCars")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarsRequest, func(models.Car) error) error); ok {
+		r0 = rf(ctx, input, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCar provides a mock function with given fields: ctx, carID
+func (_m *Service) GetCar(ctx context.Context, carID int) (models.Car, error) {
+	ret := _m.Called(ctx, carID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCar")
+	}
+
+	var r0 models.Car
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Car, error)); ok {
+		return rf(ctx, carID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Car); ok {
+		r0 = rf(ctx, carID)
+	} else {
+		r0 = ret.Get(0).(models.Car)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, carID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarByRegNum provides a mock function with given fields: ctx, regNum
+func (_m *Service) GetCarByRegNum(ctx context.Context, regNum string) (models.Car, error) {
+	ret := _m.Called(ctx, regNum)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarByRegNum")
+	}
+
+	var r0 models.Car
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.Car, error)); ok {
+		return rf(ctx, regNum)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Car); ok {
+		r0 = rf(ctx, regNum)
+	} else {
+		r0 = ret.Get(0).(models.Car)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, regNum)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarHistory provides a mock function with given fields: ctx, carID, input
+func (_m *Service) GetCarHistory(ctx context.Context, carID int, input domain.GetCarHistoryRequest) (models.AuditList, error) {
+	ret := _m.Called(ctx, carID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarHistory")
+	}
+
+	var r0 models.AuditList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarHistoryRequest) (models.AuditList, error)); ok {
+		return rf(ctx, carID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarHistoryRequest) models.AuditList); ok {
+		r0 = rf(ctx, carID, input)
+	} else {
+		r0 = ret.Get(0).(models.AuditList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.GetCarHistoryRequest) error); ok {
+		r1 = rf(ctx, carID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarOwners provides a mock function with given fields: ctx, carID, input
+func (_m *Service) GetCarOwners(ctx context.Context, carID int, input domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error) {
+	ret := _m.Called(ctx, carID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarOwners")
+	}
+
+	var r0 []models.OwnershipRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error)); ok {
+		return rf(ctx, carID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarOwnersRequest) []models.OwnershipRecord); ok {
+		r0 = rf(ctx, carID, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.OwnershipRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.GetCarOwnersRequest) error); ok {
+		r1 = rf(ctx, carID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarStats provides a mock function with given fields: ctx, input
+func (_m *Service) GetCarStats(ctx context.Context, input domain.GetCarStatsRequest) (models.CarStats, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarStats")
+	}
+
+	var r0 models.CarStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarStatsRequest) (models.CarStats, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarStatsRequest) models.CarStats); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.CarStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.GetCarStatsRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCars provides a mock function with given fields: ctx, input
 func (_m *Service) GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error) {
 	ret := _m.Called(ctx, input)
@@ -81,16 +271,164 @@ func (_m *Service) GetCars(ctx context.Context, input domain.GetCarsRequest) (mo
 	return r0, r1
 }
 
-// UpdateCar provides a mock function with given fields: ctx, carID, input
-func (_m *Service) UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest) error {
+// ImportCars provides a mock function with given fields: ctx, file
+func (_m *Service) ImportCars(ctx context.Context, file io.Reader) (models.ImportResult, error) {
+	ret := _m.Called(ctx, file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportCars")
+	}
+
+	var r0 models.ImportResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader) (models.ImportResult, error)); ok {
+		return rf(ctx, file)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader) models.ImportResult); ok {
+		r0 = rf(ctx, file)
+	} else {
+		r0 = ret.Get(0).(models.ImportResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, io.Reader) error); ok {
+		r1 = rf(ctx, file)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchCar provides a mock function with given fields: ctx, carID, patch, version
+func (_m *Service) PatchCar(ctx context.Context, carID int, patch domain.CarPatch, version *int) (int, error) {
+	ret := _m.Called(ctx, carID, patch, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchCar")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.CarPatch, *int) (int, error)); ok {
+		return rf(ctx, carID, patch, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.CarPatch, *int) int); ok {
+		r0 = rf(ctx, carID, patch, version)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.CarPatch, *int) error); ok {
+		r1 = rf(ctx, carID, patch, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeCars provides a mock function with given fields: ctx
+func (_m *Service) PurgeCars(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeCars")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreCar provides a mock function with given fields: ctx, carID
+func (_m *Service) RestoreCar(ctx context.Context, carID int) error {
+	ret := _m.Called(ctx, carID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreCar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, carID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchCars provides a mock function with given fields: ctx, input
+func (_m *Service) SearchCars(ctx context.Context, input domain.SearchCarsRequest) (models.CarList, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchCars")
+	}
+
+	var r0 models.CarList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchCarsRequest) (models.CarList, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchCarsRequest) models.CarList); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.CarList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.SearchCarsRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StreamCarEvents provides a mock function with given fields: ctx, input, send, ping
+func (_m *Service) StreamCarEvents(ctx context.Context, input domain.CarEventsRequest, send func(models.CarEvent) error, ping func() error) error {
+	ret := _m.Called(ctx, input, send, ping)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamCarEvents")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CarEventsRequest, func(models.CarEvent) error, func() error) error); ok {
+		r0 = rf(ctx, input, send, ping)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TransferCar provides a mock function with given fields: ctx, carID, input
+func (_m *Service) TransferCar(ctx context.Context, carID int, input domain.TransferCarRequest) error {
 	ret := _m.Called(ctx, carID, input)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateCar")
+		panic("no return value specified for TransferCar")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateCarsRequest) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.TransferCarRequest) error); ok {
 		r0 = rf(ctx, carID, input)
 	} else {
 		r0 = ret.Error(0)
@@ -99,6 +437,34 @@ func (_m *Service) UpdateCar(ctx context.Context, carID int, input domain.Update
 	return r0
 }
 
+// UpdateCar provides a mock function with given fields: ctx, carID, input, version
+func (_m *Service) UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest, version *int) (int, error) {
+	ret := _m.Called(ctx, carID, input, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCar")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateCarsRequest, *int) (int, error)); ok {
+		return rf(ctx, carID, input, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateCarsRequest, *int) int); ok {
+		r0 = rf(ctx, carID, input, version)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.UpdateCarsRequest, *int) error); ok {
+		r1 = rf(ctx, carID, input, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewService(t interface {
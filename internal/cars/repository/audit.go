@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/actor"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// auditBatchSize keeps inserts of many entries below the limit of query parameters.
+const auditBatchSize = 1000
+
+var auditColumns = map[string]pagination.Column[models.AuditEntry]{
+	"id": {
+		Expr:  "id",
+		Value: func(entry models.AuditEntry) any { return entry.ID },
+		Dst:   func() any { return new(int) },
+	},
+}
+
+// auditDefaultSort lists the latest changes first.
+var auditDefaultSort = []pagination.SortKey{{Field: "id", Desc: true}}
+
+// auditedCar is the state of a car kept in the audit log.
+type auditedCar struct {
+	RegNum    string
+	Mark      *string
+	Model     *string
+	Year      *int
+	OwnerID   *int
+	DeletedAt *time.Time
+	// version is not audited, it only changes together with other fields
+	version int
+}
+
+// auditedCarColumns are read into auditedCar.dst.
+const auditedCarColumns = "reg_num, mark, model, year, ownerid, deleted_at, version"
+
+// auditedFields are json names of auditedCar fields, in the order of auditedCar.values.
+var auditedFields = []string{"regNum", "mark", "model", "year", "owner_id", "deleted_at"}
+
+func (a *auditedCar) dst() []any {
+	return []any{&a.RegNum, &a.Mark, &a.Model, &a.Year, &a.OwnerID, &a.DeletedAt, &a.version}
+}
+
+func (a *auditedCar) values() []any {
+	if a == nil {
+		return make([]any, len(auditedFields))
+	}
+
+	return []any{a.RegNum, deref(a.Mark), deref(a.Model), deref(a.Year), deref(a.OwnerID), deref(a.DeletedAt)}
+}
+
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+
+	return *p
+}
+
+// carDiff lists fields changed from before to after. A nil car has no fields, so a created car
+// has all its fields changed from null.
+func carDiff(before, after *auditedCar) map[string]models.FieldChange {
+	oldValues, newValues := before.values(), after.values()
+
+	diff := make(map[string]models.FieldChange)
+
+	for i, field := range auditedFields {
+		if oldValues[i] != newValues[i] {
+			diff[field] = models.FieldChange{Old: oldValues[i], New: newValues[i]}
+		}
+	}
+
+	return diff
+}
+
+var auditInsertColumns = []string{"car_id", "actor", "operation", "diff", "mark", "owner_id"}
+
+// auditRecord is an entry of the audit log that is not written yet.
+type auditRecord struct {
+	carID         int
+	operation     string
+	before, after *auditedCar
+}
+
+// writeAudit records changes of cars in tx with the actor of ctx. Records without changed
+// fields are left out.
+func writeAudit(ctx context.Context, tx pgx.Tx, records ...auditRecord) error {
+	query := sq.Insert("car_audit").Columns(auditInsertColumns...)
+	rows := 0
+
+	var who *string
+	if name := actor.FromContext(ctx); name != "" {
+		who = &name
+	}
+
+	for _, record := range records {
+		diff := carDiff(record.before, record.after)
+		if len(diff) == 0 {
+			continue
+		}
+
+		diffBytes, err := json.Marshal(diff)
+		if err != nil {
+			return fmt.Errorf("marshal diff: %w", err)
+		}
+
+		state := record.after
+		if state == nil {
+			state = record.before
+		}
+
+		query = query.Values(record.carID, who, record.operation, diffBytes, state.Mark, state.OwnerID)
+		rows++
+
+		if rows == auditBatchSize {
+			if err = execAudit(ctx, tx, query); err != nil {
+				return err
+			}
+
+			query = sq.Insert("car_audit").Columns(auditInsertColumns...)
+			rows = 0
+		}
+	}
+
+	if rows == 0 {
+		return nil
+	}
+
+	return execAudit(ctx, tx, query)
+}
+
+func execAudit(ctx context.Context, tx pgx.Tx, query sq.InsertBuilder) error {
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("write audit: %w", err)
+	}
+
+	return nil
+}
+
+// lockCar reads the car and locks it until tx ends, deleted cars are only read with deleted.
+// With version set ErrPreconditionFailed is returned if the car has another version.
+func lockCar(ctx context.Context, tx pgx.Tx, carID int, deleted bool, version *int) (auditedCar, error) {
+	var car auditedCar
+
+	q := "SELECT " + auditedCarColumns + " FROM cars WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	if deleted {
+		q = "SELECT " + auditedCarColumns + " FROM cars WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE"
+	}
+
+	if err := tx.QueryRow(ctx, q, carID).Scan(car.dst()...); err != nil {
+		return auditedCar{}, err
+	}
+
+	if version != nil && *version != car.version {
+		return auditedCar{}, response.ErrPreconditionFailed
+	}
+
+	return car, nil
+}
+
+// GetCarHistory returns changes of the car, the latest first. History of deleted and purged
+// cars is kept.
+func (c *CarRepository) GetCarHistory(ctx context.Context, carID int, input domain.GetCarHistoryRequest) (models.AuditList, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.GetCarHistory")
+	defer span.End()
+
+	plan, err := c.auditKeyset.Plan(pagination.Request{
+		Cursor: input.Cursor,
+		Limit:  input.Limit,
+		Filter: carID,
+	})
+	if err != nil {
+		return models.AuditList{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	var exists bool
+
+	err = c.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM cars WHERE id = $1)
+			OR EXISTS(SELECT 1 FROM car_audit WHERE car_id = $1)`, carID).Scan(&exists)
+	if err != nil {
+		return models.AuditList{}, err
+	}
+
+	if !exists {
+		return models.AuditList{}, pgx.ErrNoRows
+	}
+
+	query := plan.Apply(sq.Select("id, car_id, COALESCE(actor, ''), operation, diff, created_at").
+		From("car_audit").Where(sq.Eq{"car_id": carID}))
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return models.AuditList{}, err
+	}
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return models.AuditList{}, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+
+	for rows.Next() {
+		var entry models.AuditEntry
+
+		err = rows.Scan(&entry.ID, &entry.CarID, &entry.Actor, &entry.Operation, &entry.Diff, &entry.CreatedAt)
+		if err != nil {
+			return models.AuditList{}, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.AuditList{}, err
+	}
+
+	entries, page, err := plan.Page(entries)
+	if err != nil {
+		return models.AuditList{}, err
+	}
+
+	return models.AuditList{
+		NextCursor: page.Next,
+		PrevCursor: page.Prev,
+		HasMore:    page.HasMore,
+		Entries:    entries,
+	}, nil
+}
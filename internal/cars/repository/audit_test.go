@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCarDiff(t *testing.T) {
+	mark, otherMark := "Lada", "BMW"
+	model := "Vesta"
+	year := 2020
+	ownerID, otherOwnerID := 1, 2
+	deletedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	car := auditedCar{RegNum: "X123XX150", Mark: &mark, Model: &model, Year: &year, OwnerID: &ownerID, version: 1}
+
+	tests := []struct {
+		name   string
+		before *auditedCar
+		after  *auditedCar
+		want   map[string]models.FieldChange
+	}{
+		{
+			name:  "Create",
+			after: &car,
+			want: map[string]models.FieldChange{
+				"regNum":   {Old: nil, New: "X123XX150"},
+				"mark":     {Old: nil, New: "Lada"},
+				"model":    {Old: nil, New: "Vesta"},
+				"year":     {Old: nil, New: 2020},
+				"owner_id": {Old: nil, New: 1},
+			},
+		},
+		{
+			name:   "Changed fields only",
+			before: &car,
+			after:  &auditedCar{RegNum: "X123XX150", Mark: &otherMark, Model: &model, OwnerID: &otherOwnerID, version: 2},
+			want: map[string]models.FieldChange{
+				"mark":     {Old: "Lada", New: "BMW"},
+				"year":     {Old: 2020, New: nil},
+				"owner_id": {Old: 1, New: 2},
+			},
+		},
+		{
+			name:   "Delete",
+			before: &car,
+			after:  &auditedCar{RegNum: "X123XX150", Mark: &mark, Model: &model, Year: &year, OwnerID: &ownerID, DeletedAt: &deletedAt},
+			want: map[string]models.FieldChange{
+				"deleted_at": {Old: nil, New: deletedAt},
+			},
+		},
+		{
+			name:   "Version is not a change",
+			before: &car,
+			after:  &auditedCar{RegNum: "X123XX150", Mark: &mark, Model: &model, Year: &year, OwnerID: &ownerID, version: 2},
+			want:   map[string]models.FieldChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, carDiff(tt.before, tt.after))
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"time"
+)
+
+const (
+	// carEventsChannel is notified by a trigger on every insert into car_audit.
+	carEventsChannel = "car_events"
+	// carEventsPollInterval bounds the wait for notifications. Entries written while a transaction
+	// without audit entries was running are held back until it ends, which is not notified.
+	carEventsPollInterval = 5 * time.Second
+)
+
+// finishedTxs keeps entries of transactions that could still commit out of the feed, so that
+// entries are read once their transaction and all transactions started before it ended.
+const finishedTxs = "tx_id < pg_snapshot_xmin(pg_current_snapshot())"
+
+// carEventTypes maps audit operations to types of the change feed.
+var carEventTypes = map[string]string{
+	models.AuditCreate:   models.CarEventCreated,
+	models.AuditUpdate:   models.CarEventUpdated,
+	models.AuditTransfer: models.CarEventUpdated,
+	models.AuditRestore:  models.CarEventUpdated,
+	models.AuditDelete:   models.CarEventDeleted,
+	models.AuditPurge:    models.CarEventDeleted,
+}
+
+// GetCarEvents returns up to limit events after the position matching filters of input, oldest first.
+func (c *CarRepository) GetCarEvents(ctx context.Context, after models.EventPosition, input domain.CarEventsRequest,
+	limit int) ([]models.CarEvent, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.GetCarEvents")
+	defer span.End()
+
+	query := sq.Select("id, car_id, COALESCE(actor, ''), operation, diff, created_at, mark, owner_id",
+		"diff->'mark'->>'old'", "(diff->'owner_id'->>'old')::int", "tx_id::text::bigint").
+		From("car_audit").
+		Where("(tx_id, id) > (?::text::xid8, ?)", strconv.FormatInt(after.Tx, 10), after.ID).
+		Where(finishedTxs).
+		OrderBy("tx_id", "id").
+		Limit(uint64(limit))
+
+	if input.Mark != "" {
+		query = query.Where(sq.Or{sq.Eq{"mark": input.Mark}, sq.Expr("diff->'mark'->>'old' = ?", input.Mark)})
+	}
+
+	if input.OwnerID != 0 {
+		query = query.Where(sq.Or{sq.Eq{"owner_id": input.OwnerID}, sq.Expr("(diff->'owner_id'->>'old')::int = ?", input.OwnerID)})
+	}
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := make([]models.CarEvent, 0)
+
+	for rows.Next() {
+		var event models.CarEvent
+
+		err = rows.Scan(&event.ID, &event.CarID, &event.Actor, &event.Operation, &event.Diff, &event.CreatedAt,
+			&event.Mark, &event.OwnerID, &event.PreviousMark, &event.PreviousOwnerID, &event.Position.Tx)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Position.ID = event.ID
+		event.Type = carEventTypes[event.Operation]
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// CurrentCarEventPosition returns the position before events not yet read by the feed.
+func (c *CarRepository) CurrentCarEventPosition(ctx context.Context) (models.EventPosition, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.CurrentCarEventPosition")
+	defer span.End()
+
+	var position models.EventPosition
+
+	err := c.db.QueryRow(ctx, "SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint").Scan(&position.Tx)
+	if err != nil {
+		return models.EventPosition{}, err
+	}
+
+	return position, nil
+}
+
+// ListenCarEvents calls fn once it listens to notifications of new events, after every
+// notification and when none came for a while, until ctx is done, fn fails or the connection
+// is lost. Events written before the first call are not notified, fn should read them.
+func (c *CarRepository) ListenCarEvents(ctx context.Context, fn func() error) error {
+	ctx, span := c.tracer.Start(ctx, "carRepository.ListenCarEvents")
+
+	pooled, err := c.db.Acquire(ctx)
+	if err != nil {
+		span.End()
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+
+	// the connection leaves the pool, so that it never returns to it listening
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+carEventsChannel)
+
+	// the span covers the setup only, listening lasts for the lifetime of the service
+	span.End()
+
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	for {
+		if err = fn(); err != nil {
+			return err
+		}
+
+		if err = waitForNotification(ctx, conn); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForNotification returns nil after a notification or the poll interval.
+func waitForNotification(ctx context.Context, conn *pgx.Conn) error {
+	waitCtx, cancel := context.WithTimeout(ctx, carEventsPollInterval)
+	defer cancel()
+
+	_, err := conn.WaitForNotification(waitCtx)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+)
+
+// ExportCars passes every car matching the filters of input to fn in the requested order.
+// Rows are read from the connection as fn consumes them, so the result is never held in memory.
+func (c *CarRepository) ExportCars(ctx context.Context, input domain.GetCarsRequest, fn func(car models.Car) error) error {
+	ctx, span := c.tracer.Start(ctx, "carRepository.ExportCars")
+	defer span.End()
+
+	orderBy, err := carsOrderBy(input.Sort)
+	if err != nil {
+		return fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	sql, args, err := selectCars().Where(carsFilter(input)).OrderBy(orderBy...).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(car); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// carsOrderBy builds ordering of sort like lists are ordered, without paging.
+func carsOrderBy(sort string) ([]string, error) {
+	keys, err := pagination.ParseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		keys = carsDefaultSort
+	}
+
+	orderBy := make([]string, 0, len(keys)+1)
+
+	for _, key := range keys {
+		column, ok := carColumns[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", key.Field)
+		}
+
+		direction := "ASC"
+		if key.Desc {
+			direction = "DESC"
+		}
+
+		orderBy = append(orderBy, column.Expr+" "+direction)
+	}
+
+	return append(orderBy, "cars.id ASC"), nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCarsOrderBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: []string{"cars.created_at ASC", "cars.id ASC"},
+		},
+		{
+			name: "several fields",
+			sort: "-year,owner.surname",
+			want: []string{"COALESCE(cars.year, 0) DESC", "o.surname ASC", "cars.id ASC"},
+		},
+		{
+			name:    "unknown field",
+			sort:    "color",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderBy, err := carsOrderBy(tt.sort)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, orderBy)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"slices"
+	"strings"
+)
+
+// carField is a field of a car that can be selected on its own.
+type carField struct {
+	columns []string
+	dst     func(car *models.Car) []any
+	// owner fields need the owners join
+	owner bool
+}
+
+// carFields are selectable fields by their json names, sort fields are named after them.
+var carFields = map[string]carField{
+	"id": {
+		columns: []string{"cars.id"},
+		dst:     func(car *models.Car) []any { return []any{&car.ID} },
+	},
+	"regNum": {
+		columns: []string{"cars.reg_num"},
+		dst:     func(car *models.Car) []any { return []any{&car.RegNum} },
+	},
+	"mark": {
+		columns: []string{"cars.mark"},
+		dst:     func(car *models.Car) []any { return []any{&car.Mark} },
+	},
+	"model": {
+		columns: []string{"cars.model"},
+		dst:     func(car *models.Car) []any { return []any{&car.Model} },
+	},
+	"year": {
+		columns: []string{"cars.year"},
+		dst:     func(car *models.Car) []any { return []any{&car.Year} },
+	},
+	"created_at": {
+		columns: []string{"cars.created_at"},
+		dst:     func(car *models.Car) []any { return []any{&car.CreatedAt} },
+	},
+	"updated_at": {
+		columns: []string{"cars.updated_at"},
+		dst:     func(car *models.Car) []any { return []any{&car.UpdatedAt} },
+	},
+	"deleted_at": {
+		columns: []string{"cars.deleted_at"},
+		dst:     func(car *models.Car) []any { return []any{&car.DeletedAt} },
+	},
+	"version": {
+		columns: []string{"cars.version"},
+		dst:     func(car *models.Car) []any { return []any{&car.Version} },
+	},
+	"owner": {
+		columns: []string{"o.id", "o.name", "o.surname", "COALESCE(o.patronymic, '')"},
+		dst: func(car *models.Car) []any {
+			return []any{&car.Owner.ID, &car.Owner.Name, &car.Owner.Surname, &car.Owner.Patronymic}
+		},
+		owner: true,
+	},
+}
+
+// carFieldsOrder keeps selected columns in a stable order.
+var carFieldsOrder = []string{"id", "regNum", "mark", "model", "year", "created_at", "updated_at", "deleted_at", "version", "owner"}
+
+// requestedCarFields returns fields listed in input, with the owner if it is embedded.
+// Nil means the whole car was requested.
+func requestedCarFields(input domain.GetCarsRequest) ([]string, error) {
+	if input.Fields == "" {
+		return nil, nil
+	}
+
+	fields := make([]string, 0)
+
+	for _, field := range strings.Split(input.Fields, ",") {
+		field = strings.TrimSpace(field)
+
+		if _, ok := carFields[field]; !ok || field == "owner" {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	if input.Embed == domain.EmbedOwner {
+		fields = append(fields, "owner")
+	}
+
+	return fields, nil
+}
+
+// carProjection is a select of some car fields.
+type carProjection struct {
+	fields []string
+	owner  bool
+}
+
+// newCarProjection selects requested fields together with fields the page is sorted by,
+// which are needed to create cursors. Nil requested selects every field.
+func newCarProjection(requested []string, keys []pagination.SortKey) carProjection {
+	if requested == nil {
+		return carProjection{fields: carFieldsOrder, owner: true}
+	}
+
+	needed := slices.Clone(requested)
+
+	for _, key := range keys {
+		field, _, _ := strings.Cut(key.Field, ".")
+		needed = append(needed, field)
+	}
+
+	p := carProjection{}
+
+	for _, field := range carFieldsOrder {
+		if slices.Contains(needed, field) {
+			p.fields = append(p.fields, field)
+			p.owner = p.owner || carFields[field].owner
+		}
+	}
+
+	return p
+}
+
+// query selects the projection. Owners are only joined when they are selected or filtered by,
+// otherwise cars without an owner are excluded like the join does.
+func (p carProjection) query(input domain.GetCarsRequest) sq.SelectBuilder {
+	columns := make([]string, 0, len(p.fields))
+
+	for _, field := range p.fields {
+		columns = append(columns, carFields[field].columns...)
+	}
+
+	query := sq.Select(columns...).From("cars")
+
+	if p.owner || input.OwnerName != "" || input.OwnerSurname != "" || input.OwnerPatronymic != "" {
+		return query.InnerJoin("owners o on o.id = cars.ownerid")
+	}
+
+	return query.Where(sq.NotEq{"cars.ownerid": nil})
+}
+
+func (p carProjection) scan(row pgx.Row) (models.Car, error) {
+	var car models.Car
+
+	dst := make([]any, 0, len(p.fields))
+
+	for _, field := range p.fields {
+		dst = append(dst, carFields[field].dst(&car)...)
+	}
+
+	if err := row.Scan(dst...); err != nil {
+		return models.Car{}, err
+	}
+
+	return car, nil
+}
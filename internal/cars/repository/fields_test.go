@@ -0,0 +1,82 @@
+package repository
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCarProjection(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      domain.GetCarsRequest
+		keys       []pagination.SortKey
+		wantFields []string
+		wantSql    string
+		wantErr    bool
+	}{
+		{
+			name:    "whole car",
+			input:   domain.GetCarsRequest{},
+			keys:    []pagination.SortKey{{Field: "created_at"}, {Field: "id"}},
+			wantSql: "SELECT cars.id, cars.reg_num, cars.mark, cars.model, cars.year, cars.created_at, cars.updated_at, cars.deleted_at, cars.version, o.id, o.name, o.surname, COALESCE(o.patronymic, '') FROM cars INNER JOIN owners o on o.id = cars.ownerid",
+		},
+		{
+			name:       "sort fields are selected",
+			input:      domain.GetCarsRequest{Fields: "regNum, id,regNum"},
+			keys:       []pagination.SortKey{{Field: "created_at"}, {Field: "id"}},
+			wantFields: []string{"regNum", "id"},
+			wantSql:    "SELECT cars.id, cars.reg_num, cars.created_at FROM cars WHERE cars.ownerid IS NOT NULL",
+		},
+		{
+			name:       "embedded owner",
+			input:      domain.GetCarsRequest{Fields: "regNum", Embed: domain.EmbedOwner},
+			keys:       []pagination.SortKey{{Field: "id"}},
+			wantFields: []string{"regNum", "owner"},
+			wantSql:    "SELECT cars.id, cars.reg_num, o.id, o.name, o.surname, COALESCE(o.patronymic, '') FROM cars INNER JOIN owners o on o.id = cars.ownerid",
+		},
+		{
+			name:       "sort by owner",
+			input:      domain.GetCarsRequest{Fields: "regNum"},
+			keys:       []pagination.SortKey{{Field: "owner.surname"}, {Field: "id"}},
+			wantFields: []string{"regNum"},
+			wantSql:    "SELECT cars.id, cars.reg_num, o.id, o.name, o.surname, COALESCE(o.patronymic, '') FROM cars INNER JOIN owners o on o.id = cars.ownerid",
+		},
+		{
+			name:       "filter by owner",
+			input:      domain.GetCarsRequest{Fields: "regNum", OwnerSurname: "Ivanov"},
+			keys:       []pagination.SortKey{{Field: "id"}},
+			wantFields: []string{"regNum"},
+			wantSql:    "SELECT cars.id, cars.reg_num FROM cars INNER JOIN owners o on o.id = cars.ownerid",
+		},
+		{
+			name:    "unknown field",
+			input:   domain.GetCarsRequest{Fields: "id,color"},
+			wantErr: true,
+		},
+		{
+			name:    "owner is embedded, not listed",
+			input:   domain.GetCarsRequest{Fields: "id,owner"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested, err := requestedCarFields(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFields, requested)
+
+			sql, _, err := newCarProjection(requested, tt.keys).query(tt.input).PlaceholderFormat(sq.Dollar).ToSql()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSql, sql)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRepository connects to the migrated database of TEST_DATABASE_URL, tests using it are
+// skipped without it.
+func testRepository(t *testing.T) (*CarRepository, *pgxpool.Pool) {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+
+	db, err := pgxpool.New(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	paginator := pagination.NewPaginator("secret", 10, 100)
+
+	return NewCarRepository(db, paginator, tracer.InitTracer(ctx, "", "")), db
+}
+
+func TestCarRepository_CreateCarsConcurrently(t *testing.T) {
+	repo, db := testRepository(t)
+	ctx := context.Background()
+
+	prefix := fmt.Sprintf("T%d", time.Now().UnixNano()%1_000_000_000)
+
+	t.Cleanup(func() {
+		_, _ = db.Exec(ctx, "DELETE FROM car_audit WHERE diff->'regNum'->>'new' LIKE $1", prefix+"%")
+		_, _ = db.Exec(ctx, "DELETE FROM cars WHERE reg_num LIKE $1", prefix+"%")
+		_, _ = db.Exec(ctx, "DELETE FROM owners WHERE name LIKE $1", prefix+"%")
+	})
+
+	start, err := repo.CurrentCarEventPosition(ctx)
+	require.NoError(t, err)
+
+	const (
+		rounds = 20
+		plates = 10
+	)
+
+	created := make(map[int]string)
+
+	for round := 0; round < rounds; round++ {
+		cars := make([]domain.Car, plates)
+		for i := range cars {
+			cars[i] = domain.Car{
+				RegNum: fmt.Sprintf("%s-%d-%d", prefix, round, i),
+				Mark:   "Lada",
+				Model:  "Vesta",
+				Owner:  domain.People{Name: fmt.Sprintf("%s-%d", prefix, i%3), Surname: "Ivanov"},
+			}
+		}
+
+		// the second batch has the same plates and owners in reverse order
+		reversed := slices.Clone(cars)
+		slices.Reverse(reversed)
+
+		var wg sync.WaitGroup
+
+		results := make([]models.CreateCarsResult, 2)
+		errs := make([]error, 2)
+
+		for i, batch := range [][]domain.Car{cars, reversed} {
+			wg.Add(1)
+
+			go func(i int, batch []domain.Car) {
+				defer wg.Done()
+				results[i], errs[i] = repo.CreateCars(ctx, batch, domain.OnConflictSkip)
+			}(i, batch)
+		}
+
+		wg.Wait()
+
+		require.NoError(t, errs[0])
+		require.NoError(t, errs[1])
+		require.Len(t, append(results[0].Skipped, results[1].Skipped...), plates)
+
+		for _, result := range results {
+			for _, car := range result.Created {
+				created[car.ID] = car.RegNum
+			}
+		}
+	}
+
+	require.Len(t, created, rounds*plates)
+
+	// every created car is in the feed once, although ids of the batches were committed out of order
+	seen := make(map[int]int)
+	last := start
+
+	for {
+		events, err := repo.GetCarEvents(ctx, last, domain.CarEventsRequest{}, 500)
+		require.NoError(t, err)
+
+		for _, event := range events {
+			require.True(t, last.Less(event.Position))
+			last = event.Position
+
+			if _, ok := created[event.CarID]; ok && event.Operation == models.AuditCreate {
+				seen[event.CarID]++
+			}
+		}
+
+		if len(events) < 500 {
+			break
+		}
+	}
+
+	require.Len(t, seen, len(created))
+
+	for carID, count := range seen {
+		require.Equal(t, 1, count, created[carID])
+	}
+}
+
+func TestSearchCars_UsesIndexes(t *testing.T) {
+	_, db := testRepository(t)
+	ctx := context.Background()
+
+	sql, args, err := rankedSearch("Ivanov", "ivanov:*").PlaceholderFormat(sq.Dollar).ToSql()
+	require.NoError(t, err)
+
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+
+	defer tx.Rollback(ctx)
+
+	// small test tables are scanned sequentially anyway, the plan must be able to do without it
+	_, err = tx.Exec(ctx, "SET LOCAL enable_seqscan = off")
+	require.NoError(t, err)
+
+	rows, err := tx.Query(ctx, "EXPLAIN "+sql, args...)
+	require.NoError(t, err)
+
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+
+	plan := strings.Join(lines, "\n")
+
+	for _, index := range []string{"idx_cars_search_vector", "idx_cars_reg_num_trgm", "idx_owners_search_vector",
+		"idx_owners_surname_trgm", "idx_cars_ownerid"} {
+		require.Contains(t, plan, index)
+	}
+}
@@ -2,100 +2,326 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/Verce11o/effective-mobile-test/internal/domain"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/postgres"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
 	"github.com/Verce11o/effective-mobile-test/internal/models"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel/trace"
-)
-
-const (
-	paginationLimit = 10
+	"slices"
+	"strings"
+	"time"
 )
 
 type CarRepository struct {
-	db     *pgxpool.Pool
-	tracer trace.Tracer
+	db           *pgxpool.Pool
+	keyset       *pagination.Keyset[models.Car]
+	searchKeyset *pagination.Keyset[rankedCar]
+	auditKeyset  *pagination.Keyset[models.AuditEntry]
+	tracer       trace.Tracer
 }
 
-func NewCarRepository(db *pgxpool.Pool, tracer trace.Tracer) *CarRepository {
-	return &CarRepository{db: db, tracer: tracer}
+func NewCarRepository(db *pgxpool.Pool, paginator *pagination.Paginator, tracer trace.Tracer) *CarRepository {
+	return &CarRepository{
+		db:           db,
+		keyset:       pagination.NewKeyset(paginator, carColumns, carsDefaultSort, "id"),
+		searchKeyset: pagination.NewKeyset(paginator, rankedCarColumns, searchDefaultSort, "id"),
+		auditKeyset:  pagination.NewKeyset(paginator, auditColumns, auditDefaultSort, "id"),
+		tracer:       tracer,
+	}
 }
 
-func (c *CarRepository) CreateCars(ctx context.Context, input []domain.Car) error {
+// CreateCars inserts cars in one transaction. Plates that already exist are handled
+// according to onConflict: fail returns ConflictError listing all of them, skip leaves
+// them out and return adds the stored cars to the result.
+func (c *CarRepository) CreateCars(ctx context.Context, input []domain.Car, onConflict string) (models.CreateCarsResult, error) {
 	ctx, span := c.tracer.Start(ctx, "carRepository.CreateCars")
 	defer span.End()
 
 	tx, err := c.db.Begin(ctx)
 
 	if err != nil {
-		return fmt.Errorf("error begin transaction: %w", err)
+		return models.CreateCarsResult{}, fmt.Errorf("error begin transaction: %w", err)
 	}
 
 	defer tx.Rollback(ctx)
 
+	regNums := make([]string, 0, len(input))
 	for _, car := range input {
-		var ownerID int
-		row := tx.QueryRow(ctx, "INSERT INTO owners (name, surname, patronymic) VALUES ($1, $2, $3) RETURNING id", car.Owner.Name, car.Owner.Surname, car.Owner.Patronymic)
+		regNums = append(regNums, car.RegNum)
+	}
+
+	rows, err := tx.Query(ctx, "SELECT reg_num FROM cars WHERE reg_num = ANY($1) AND deleted_at IS NULL", regNums)
+	if err != nil {
+		return models.CreateCarsResult{}, err
+	}
+
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return models.CreateCarsResult{}, err
+	}
+
+	conflicts := make(map[string]struct{}, len(existing))
+	for _, regNum := range existing {
+		conflicts[regNum] = struct{}{}
+	}
+
+	// owners and plates are locked in the same order by every batch, so that concurrent batches
+	// sharing some of them wait for each other instead of deadlocking
+	ordered := make([]domain.Car, 0, len(input))
+	for _, car := range input {
+		if _, ok := conflicts[car.RegNum]; !ok {
+			ordered = append(ordered, car)
+		}
+	}
+
+	ownerIDs, err := c.resolveOwners(ctx, tx, ordered)
+	if err != nil {
+		return models.CreateCarsResult{}, err
+	}
+
+	slices.SortFunc(ordered, func(a, b domain.Car) int {
+		return strings.Compare(a.RegNum, b.RegNum)
+	})
+
+	inserted := make(map[string]models.Car, len(input))
+
+	for _, car := range ordered {
+		created, err := c.insertCar(ctx, tx, car, ownerIDs[newOwnerKey(car.Owner)])
 
-		if err := row.Scan(&ownerID); err != nil {
-			return err
+		// plate was inserted concurrently after the check above
+		if errors.Is(err, pgx.ErrNoRows) {
+			conflicts[car.RegNum] = struct{}{}
+			continue
 		}
-		_, err = tx.Exec(ctx, "INSERT INTO cars (reg_num, mark, model, year, ownerid) VALUES ($1, $2, $3, $4, $5)", car.RegNum, car.Mark, car.Model, car.Year, ownerID)
+
 		if err != nil {
-			return err
+			return models.CreateCarsResult{}, err
 		}
+
+		inserted[car.RegNum] = created
 	}
 
-	return tx.Commit(ctx)
+	result := models.CreateCarsResult{
+		Created: make([]models.Car, 0, len(inserted)),
+	}
+
+	for _, car := range input {
+		if created, ok := inserted[car.RegNum]; ok {
+			result.Created = append(result.Created, created)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		conflicting := make([]string, 0, len(conflicts))
+		for _, car := range input {
+			if _, ok := conflicts[car.RegNum]; ok {
+				conflicting = append(conflicting, car.RegNum)
+			}
+		}
+
+		switch onConflict {
+		case domain.OnConflictSkip:
+			result.Skipped = conflicting
+		case domain.OnConflictReturn:
+			result.Existing, err = c.getCarsByRegNums(ctx, tx, conflicting)
+			if err != nil {
+				return models.CreateCarsResult{}, err
+			}
+		default:
+			return models.CreateCarsResult{}, &response.ConflictError{RegNums: conflicting}
+		}
+	}
+
+	return result, tx.Commit(ctx)
 }
 
-func (c *CarRepository) GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error) {
-	ctx, span := c.tracer.Start(ctx, "carRepository.GetCars")
-	defer span.End()
+// insertCar returns pgx.ErrNoRows if a car with the same reg num already exists.
+func (c *CarRepository) insertCar(ctx context.Context, tx pgx.Tx, car domain.Car, ownerID int) (models.Car, error) {
+	created := models.Car{
+		RegNum: car.RegNum,
+		Mark:   car.Mark,
+		Model:  car.Model,
+		Year:   car.Year,
+		Owner: models.People{
+			ID:      ownerID,
+			Name:    car.Owner.Name,
+			Surname: car.Owner.Surname,
+		},
+	}
 
-	var id int
-	var err error
+	if car.Owner.Patronymic != nil {
+		created.Owner.Patronymic = *car.Owner.Patronymic
+	}
 
-	if input.Cursor != "" {
-		id, err = pagination.DecodeCursor(input.Cursor)
+	q := `INSERT INTO cars (reg_num, mark, model, year, ownerid) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (reg_num) WHERE deleted_at IS NULL DO NOTHING RETURNING id, created_at, updated_at`
 
+	err := tx.QueryRow(ctx, q, car.RegNum, car.Mark, car.Model, car.Year, ownerID).Scan(&created.ID, &created.CreatedAt, &created.UpdatedAt)
+	if err != nil {
+		return models.Car{}, err
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO ownership_history (car_id, previous_owner_id, new_owner_id, effective_at) VALUES ($1, NULL, $2, $3)",
+		created.ID, ownerID, created.CreatedAt)
+	if err != nil {
+		return models.Car{}, err
+	}
+
+	after := auditedCar{RegNum: car.RegNum, Mark: &car.Mark, Model: &car.Model, Year: car.Year, OwnerID: &ownerID}
+
+	err = writeAudit(ctx, tx, auditRecord{carID: created.ID, operation: models.AuditCreate, after: &after})
+	if err != nil {
+		return models.Car{}, err
+	}
+
+	return created, nil
+}
+
+func (c *CarRepository) getCarsByRegNums(ctx context.Context, tx pgx.Tx, regNums []string) ([]models.Car, error) {
+	sql, args, err := selectCars().Where(sq.Eq{"cars.reg_num": regNums, "cars.deleted_at": nil}).
+		OrderBy("cars.id").PlaceholderFormat(sq.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	cars := make([]models.Car, 0, len(regNums))
+
+	for rows.Next() {
+		car, err := scanCar(rows)
 		if err != nil {
-			return models.CarList{}, err
+			return nil, err
 		}
+
+		cars = append(cars, car)
 	}
 
-	query := sq.Select("cars.id, cars.reg_num, cars.mark, cars.model, cars.year, cars.created_at, o.id, o.name, o.surname, o.patronymic").
-		From("cars").InnerJoin("owners o on o.id = cars.ownerid").
-		OrderBy("cars.created_at, cars.id").Limit(paginationLimit)
+	return cars, rows.Err()
+}
 
-	if id != 0 {
-		query = query.Where(sq.Gt{"cars.id": id})
+// ownerKey identifies an owner like the unique index of owners does.
+type ownerKey struct {
+	name, surname, patronymic string
+}
+
+func newOwnerKey(owner domain.People) ownerKey {
+	key := ownerKey{name: owner.Name, surname: owner.Surname}
+	if owner.Patronymic != nil {
+		key.patronymic = *owner.Patronymic
 	}
 
-	if input.RegNum != "" {
-		query = query.Where(sq.Eq{"cars.reg_num": input.RegNum})
+	return key
+}
+
+func (k ownerKey) compare(other ownerKey) int {
+	if c := strings.Compare(k.name, other.name); c != 0 {
+		return c
 	}
 
-	if input.Mark != "" {
-		query = query.Where(sq.Eq{"cars.mark": input.Mark})
+	if c := strings.Compare(k.surname, other.surname); c != 0 {
+		return c
 	}
 
-	if input.Model != "" {
-		query = query.Where(sq.Eq{"cars.model": input.Model})
+	return strings.Compare(k.patronymic, other.patronymic)
+}
+
+// resolveOwners resolves owners of cars once each, in the order of their keys.
+func (c *CarRepository) resolveOwners(ctx context.Context, tx pgx.Tx, cars []domain.Car) (map[ownerKey]int, error) {
+	owners := make(map[ownerKey]domain.People, len(cars))
+	keys := make([]ownerKey, 0, len(cars))
+
+	for _, car := range cars {
+		key := newOwnerKey(car.Owner)
+		if _, ok := owners[key]; !ok {
+			owners[key] = car.Owner
+			keys = append(keys, key)
+		}
 	}
 
-	if input.Year != 0 {
-		query = query.Where(sq.Eq{"cars.year": input.Year})
+	slices.SortFunc(keys, ownerKey.compare)
+	ids := make(map[ownerKey]int, len(keys))
+
+	for _, key := range keys {
+		ownerID, err := c.resolveOwner(ctx, tx, owners[key])
+		if err != nil {
+			return nil, err
+		}
+
+		ids[key] = ownerID
 	}
 
-	if input.OwnerID != 0 {
-		query = query.Where(sq.Eq{"cars.ownerid": input.OwnerID})
+	return ids, nil
+}
+
+// resolveOwner returns id of the owner with the same name, surname and patronymic,
+// creating one if such person is not known yet.
+func (c *CarRepository) resolveOwner(ctx context.Context, tx pgx.Tx, owner domain.People) (int, error) {
+	q := `INSERT INTO owners (name, surname, patronymic) VALUES ($1, $2, $3)
+			ON CONFLICT (name, surname, COALESCE(patronymic, '')) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`
+
+	var ownerID int
+
+	if err := tx.QueryRow(ctx, q, owner.Name, owner.Surname, owner.Patronymic).Scan(&ownerID); err != nil {
+		return 0, fmt.Errorf("resolve owner: %w", err)
+	}
+
+	return ownerID, nil
+}
+
+func (c *CarRepository) GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.GetCars")
+	defer span.End()
+
+	// a cursor is only valid for the filters it was created with
+	filter := input
+	filter.Cursor, filter.Sort, filter.Limit = "", "", 0
+	filter.IncludeTotal, filter.TotalMode = false, ""
+	filter.Fields, filter.Embed = "", ""
+
+	requested, err := requestedCarFields(input)
+	if err != nil {
+		return models.CarList{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	plan, err := c.keyset.Plan(pagination.Request{
+		Cursor: input.Cursor,
+		Sort:   input.Sort,
+		Limit:  input.Limit,
+		Filter: filter,
+	})
+	if err != nil {
+		return models.CarList{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
 	}
 
+	// the latest change is read before the page, so the page is never older than it. Purged cars
+	// leave no trace in it, so it is not known for lists with deleted cars
+	var lastModified *time.Time
+
+	if !input.IncludeDeleted {
+		if err = c.db.QueryRow(ctx, "SELECT MAX(updated_at) FROM cars").Scan(&lastModified); err != nil {
+			return models.CarList{}, err
+		}
+	}
+
+	projection := newCarProjection(requested, plan.Keys())
+
+	query := plan.Apply(projection.query(input)).Where(carsFilter(input))
+
 	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
 
 	if err != nil {
@@ -112,9 +338,7 @@ func (c *CarRepository) GetCars(ctx context.Context, input domain.GetCarsRequest
 	cars := make([]models.Car, 0)
 
 	for rows.Next() {
-		var car models.Car
-
-		err = rows.Scan(&car.ID, &car.RegNum, &car.Mark, &car.Model, &car.Year, &car.CreatedAt, &car.Owner.ID, &car.Owner.Name, &car.Owner.Surname, &car.Owner.Patronymic)
+		car, err := projection.scan(rows)
 		if err != nil {
 			return models.CarList{}, err
 		}
@@ -123,22 +347,181 @@ func (c *CarRepository) GetCars(ctx context.Context, input domain.GetCarsRequest
 
 	}
 
-	var nextCursor string
-
-	if len(cars) > 0 {
-		last := cars[len(cars)-1]
-		nextCursor = pagination.EncodeCursor(last.ID)
+	if err = rows.Err(); err != nil {
+		return models.CarList{}, err
+	}
 
+	cars, page, err := plan.Page(cars)
+	if err != nil {
+		return models.CarList{}, err
 	}
+
 	return models.CarList{
-		Cursor: nextCursor,
-		Cars:   cars,
-		Total:  len(cars),
+		NextCursor:   page.Next,
+		PrevCursor:   page.Prev,
+		HasMore:      page.HasMore,
+		LastModified: lastModified,
+		Fields:       requested,
+		Cars:         cars,
 	}, nil
 }
 
-func (c *CarRepository) UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest) error {
-	ctx, span := c.tracer.Start(ctx, "carRepository.UpdateCar")
+// CountCars returns the number of cars matching filters of input. With estimate the number
+// is taken from the planner statistics, which is fast but may be far from the exact count.
+func (c *CarRepository) CountCars(ctx context.Context, input domain.GetCarsRequest, estimate bool) (int, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.CountCars")
+	defer span.End()
+
+	query := sq.Select("COUNT(*)")
+
+	if estimate {
+		// rows of the plain select are estimated by the top plan node, while the count would
+		// be estimated by an aggregate node, which can be split between parallel workers
+		query = sq.Select("1").Prefix("EXPLAIN (FORMAT JSON)")
+	}
+
+	query = query.From("cars").InnerJoin("owners o on o.id = cars.ownerid").Where(carsFilter(input))
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	if !estimate {
+		var count int
+
+		if err = c.db.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+			return 0, err
+		}
+
+		return count, nil
+	}
+
+	var planBytes []byte
+
+	if err = c.db.QueryRow(ctx, sql, args...).Scan(&planBytes); err != nil {
+		return 0, err
+	}
+
+	var plan []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+
+	if err = json.Unmarshal(planBytes, &plan); err != nil {
+		return 0, fmt.Errorf("parse plan: %w", err)
+	}
+
+	if len(plan) == 0 {
+		return 0, fmt.Errorf("parse plan: unexpected plan %s", planBytes)
+	}
+
+	return int(plan[0].Plan.Rows), nil
+}
+
+func (c *CarRepository) GetCar(ctx context.Context, carID int) (models.Car, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.GetCar")
+	defer span.End()
+
+	return c.getCarBy(ctx, sq.Eq{"cars.id": carID})
+}
+
+func (c *CarRepository) GetCarByRegNum(ctx context.Context, regNum string) (models.Car, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.GetCarByRegNum")
+	defer span.End()
+
+	return c.getCarBy(ctx, sq.Eq{"cars.reg_num": regNum})
+}
+
+// getCarBy ignores deleted cars.
+func (c *CarRepository) getCarBy(ctx context.Context, pred sq.Eq) (models.Car, error) {
+	sql, args, err := selectCars().Where(pred).Where(sq.Eq{"cars.deleted_at": nil}).
+		OrderBy("cars.id").Limit(1).PlaceholderFormat(sq.Dollar).ToSql()
+
+	if err != nil {
+		return models.Car{}, err
+	}
+
+	return scanCar(c.db.QueryRow(ctx, sql, args...))
+}
+
+// PatchCar updates fields set in patch and returns the new version of the car. Only fields
+// of the patch are written, so concurrent patches of other fields are not lost. With version
+// set the car is only updated if it still has that version. An empty patch only checks the car.
+func (c *CarRepository) PatchCar(ctx context.Context, carID int, patch domain.CarPatch, version *int) (int, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.PatchCar")
+	defer span.End()
+
+	set := make(map[string]any)
+
+	if patch.RegNum.Set {
+		set["reg_num"] = patch.RegNum.Value
+	}
+
+	if patch.Mark.Set {
+		set["mark"] = patch.Mark.Value
+	}
+
+	if patch.Model.Set {
+		set["model"] = patch.Model.Value
+	}
+
+	if patch.Year.Set {
+		set["year"] = patch.Year.Value
+	}
+
+	tx, err := c.db.Begin(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("error begin transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	before, err := lockCar(ctx, tx, carID, false, version)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(set) == 0 {
+		return before.version, nil
+	}
+
+	set["version"] = sq.Expr("version + 1")
+	set["updated_at"] = sq.Expr("NOW() AT TIME ZONE 'utc'")
+
+	sql, args, err := sq.Update("cars").SetMap(set).
+		Where(sq.Eq{"id": carID}).
+		Suffix("RETURNING " + auditedCarColumns).
+		PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var after auditedCar
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(after.dst()...)
+
+	if postgres.IsUniqueViolation(err) {
+		return 0, &response.ConflictError{RegNums: []string{*patch.RegNum.Value}}
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = writeAudit(ctx, tx, auditRecord{carID: carID, operation: models.AuditUpdate, before: &before, after: &after})
+	if err != nil {
+		return 0, err
+	}
+
+	return after.version, tx.Commit(ctx)
+}
+
+// DeleteCar marks the car as deleted. With version set the car is only deleted if it still has that version.
+func (c *CarRepository) DeleteCar(ctx context.Context, carID int, version *int) error {
+	ctx, span := c.tracer.Start(ctx, "carRepository.DeleteCar")
 	defer span.End()
 
 	tx, err := c.db.Begin(ctx)
@@ -149,55 +532,332 @@ func (c *CarRepository) UpdateCar(ctx context.Context, carID int, input domain.U
 
 	defer tx.Rollback(ctx)
 
-	var exists bool
-	findCar := `SELECT EXISTS(SELECT 1 FROM cars WHERE id = $1)`
+	before, err := lockCar(ctx, tx, carID, false, version)
+	if err != nil {
+		return err
+	}
 
-	err = tx.QueryRow(ctx, findCar, carID).Scan(&exists)
+	var after auditedCar
 
+	err = tx.QueryRow(ctx, `UPDATE cars SET deleted_at = NOW() AT TIME ZONE 'utc', version = version + 1,
+			updated_at = NOW() AT TIME ZONE 'utc' WHERE id = $1 RETURNING `+auditedCarColumns, carID).Scan(after.dst()...)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		return pgx.ErrNoRows
+	err = writeAudit(ctx, tx, auditRecord{carID: carID, operation: models.AuditDelete, before: &before, after: &after})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RestoreCar returns ConflictError if the plate was taken by another car after deletion.
+func (c *CarRepository) RestoreCar(ctx context.Context, carID int) error {
+	ctx, span := c.tracer.Start(ctx, "carRepository.RestoreCar")
+	defer span.End()
+
+	tx, err := c.db.Begin(ctx)
+
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
 	}
 
-	q := `UPDATE cars SET reg_num = COALESCE(NULLIF($1, ''), reg_num),
-                mark = COALESCE(NULLIF($2, ''), mark),
-				model = COALESCE(NULLIF($3, ''), model), 
-				year = COALESCE(NULLIF($4, 0), year) WHERE id = $5`
+	defer tx.Rollback(ctx)
+
+	before, err := lockCar(ctx, tx, carID, true, nil)
+	if err != nil {
+		return err
+	}
+
+	var after auditedCar
+
+	err = tx.QueryRow(ctx, `UPDATE cars SET deleted_at = NULL, version = version + 1, updated_at = NOW() AT TIME ZONE 'utc'
+			WHERE id = $1 RETURNING `+auditedCarColumns, carID).Scan(after.dst()...)
+
+	if postgres.IsUniqueViolation(err) {
+		return &response.ConflictError{RegNums: []string{before.RegNum}}
+	}
+
+	if err != nil {
+		return err
+	}
 
-	_, err = tx.Exec(ctx, q, input.RegNum, input.Mark, input.Model, input.Year, carID)
+	err = writeAudit(ctx, tx, auditRecord{carID: carID, operation: models.AuditRestore, before: &before, after: &after})
 	if err != nil {
 		return err
 	}
 
 	return tx.Commit(ctx)
+}
+
+// PurgeCars permanently removes cars deleted before deletedBefore and returns their ids.
+func (c *CarRepository) PurgeCars(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.PurgeCars")
+	defer span.End()
+
+	tx, err := c.db.Begin(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("error begin transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx)
 
+	rows, err := tx.Query(ctx, "DELETE FROM cars WHERE deleted_at < $1 RETURNING id, "+auditedCarColumns, deletedBefore.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	records := make([]auditRecord, 0)
+
+	for rows.Next() {
+		var (
+			id     int
+			before auditedCar
+		)
+
+		if err = rows.Scan(append([]any{&id}, before.dst()...)...); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+		records = append(records, auditRecord{carID: id, operation: models.AuditPurge, before: &before})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = writeAudit(ctx, tx, records...); err != nil {
+		return nil, err
+	}
+
+	return ids, tx.Commit(ctx)
 }
 
-func (c *CarRepository) DeleteCar(ctx context.Context, carID int) error {
-	ctx, span := c.tracer.Start(ctx, "carRepository.DeleteCar")
+func (c *CarRepository) TransferCar(ctx context.Context, carID int, input domain.TransferCarRequest) error {
+	ctx, span := c.tracer.Start(ctx, "carRepository.TransferCar")
 	defer span.End()
 
 	tx, err := c.db.Begin(ctx)
+
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+
 	defer tx.Rollback(ctx)
 
+	before, err := lockCar(ctx, tx, carID, false, nil)
+	if err != nil {
+		return err
+	}
+
+	currentOwnerID := before.OwnerID
+
+	if currentOwnerID != nil && *currentOwnerID == input.OwnerID {
+		return fmt.Errorf("car already belongs to owner %d: %w", input.OwnerID, response.ErrInvalidRequest)
+	}
+
+	var ownerExists bool
+
+	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM owners WHERE id = $1)", input.OwnerID).Scan(&ownerExists)
 	if err != nil {
 		return err
 	}
 
-	q := "DELETE FROM cars WHERE id = $1"
+	if !ownerExists {
+		return fmt.Errorf("owner %d: %w", input.OwnerID, response.ErrNotFound)
+	}
+
+	effectiveAt := time.Now().UTC()
+	if input.EffectiveAt != nil {
+		effectiveAt = input.EffectiveAt.UTC()
+	}
 
-	tag, err := tx.Exec(ctx, q, carID)
+	var lastEffectiveAt *time.Time
 
+	err = tx.QueryRow(ctx, "SELECT MAX(effective_at) FROM ownership_history WHERE car_id = $1", carID).Scan(&lastEffectiveAt)
 	if err != nil {
 		return err
 	}
 
-	if tag.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+	if lastEffectiveAt != nil && effectiveAt.Before(*lastEffectiveAt) {
+		return fmt.Errorf("effective date is before the last transfer: %w", response.ErrInvalidRequest)
+	}
+
+	var after auditedCar
+
+	err = tx.QueryRow(ctx, `UPDATE cars SET ownerid = $1, version = version + 1, updated_at = NOW() AT TIME ZONE 'utc'
+			WHERE id = $2 RETURNING `+auditedCarColumns, input.OwnerID, carID).Scan(after.dst()...)
+	if err != nil {
+		return err
+	}
+
+	err = writeAudit(ctx, tx, auditRecord{carID: carID, operation: models.AuditTransfer, before: &before, after: &after})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO ownership_history (car_id, previous_owner_id, new_owner_id, effective_at) VALUES ($1, $2, $3, $4)",
+		carID, currentOwnerID, input.OwnerID, effectiveAt)
+	if err != nil {
+		return err
 	}
 
 	return tx.Commit(ctx)
 }
+
+func (c *CarRepository) GetCarOwners(ctx context.Context, carID int, input domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.GetCarOwners")
+	defer span.End()
+
+	var exists bool
+
+	err := c.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM cars WHERE id = $1 AND deleted_at IS NULL)", carID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+
+	history := sq.Select("h.effective_at, LEAD(h.effective_at) OVER (ORDER BY h.effective_at, h.id) AS effective_to",
+		"p.id AS prev_id, p.name AS prev_name, p.surname AS prev_surname, p.patronymic AS prev_patronymic",
+		"o.id, o.name, o.surname, COALESCE(o.patronymic, '') AS patronymic").
+		From("ownership_history h").
+		InnerJoin("owners o ON o.id = h.new_owner_id").
+		LeftJoin("owners p ON p.id = h.previous_owner_id").
+		Where(sq.Eq{"h.car_id": carID})
+
+	query := sq.Select("*").FromSelect(history, "chain").OrderBy("effective_at")
+
+	if !input.At.IsZero() {
+		query = query.Where(sq.LtOrEq{"effective_at": input.At.UTC()}).
+			Where(sq.Or{sq.Eq{"effective_to": nil}, sq.Gt{"effective_to": input.At.UTC()}})
+	}
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	records := make([]models.OwnershipRecord, 0)
+
+	for rows.Next() {
+		var record models.OwnershipRecord
+		var prevID *int
+		var prevName, prevSurname, prevPatronymic *string
+
+		err = rows.Scan(&record.EffectiveFrom, &record.EffectiveTo, &prevID, &prevName, &prevSurname, &prevPatronymic,
+			&record.Owner.ID, &record.Owner.Name, &record.Owner.Surname, &record.Owner.Patronymic)
+		if err != nil {
+			return nil, err
+		}
+
+		if prevID != nil {
+			record.PreviousOwner = &models.People{ID: *prevID, Name: *prevName, Surname: *prevSurname}
+			if prevPatronymic != nil {
+				record.PreviousOwner.Patronymic = *prevPatronymic
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// carsFilter returns predicates of GetCars filters, shared by listing and counting.
+func carsFilter(input domain.GetCarsRequest) sq.And {
+	pred := sq.And{}
+
+	if input.RegNum != "" {
+		pred = append(pred, sq.Eq{"cars.reg_num": input.RegNum})
+	}
+
+	if input.Mark != "" {
+		pred = append(pred, sq.Eq{"cars.mark": input.Mark})
+	}
+
+	if input.Model != "" {
+		pred = append(pred, sq.Eq{"cars.model": input.Model})
+	}
+
+	if input.Year != 0 {
+		pred = append(pred, sq.Eq{"cars.year": input.Year})
+	}
+
+	if input.OwnerID != 0 {
+		pred = append(pred, sq.Eq{"cars.ownerid": input.OwnerID})
+	}
+
+	ownerFilters := []struct{ column, value string }{
+		{"o.name", input.OwnerName},
+		{"o.surname", input.OwnerSurname},
+		{"o.patronymic", input.OwnerPatronymic},
+	}
+
+	for _, filter := range ownerFilters {
+		if filter.value != "" {
+			pred = append(pred, matchText(filter.column, filter.value, input.OwnerMatch, input.OwnerIgnoreCase))
+		}
+	}
+
+	if !input.IncludeDeleted {
+		pred = append(pred, sq.Eq{"cars.deleted_at": nil})
+	}
+
+	return pred
+}
+
+// matchText compares column with value exactly or by prefix, optionally ignoring case.
+func matchText(column, value, match string, ignoreCase bool) sq.Sqlizer {
+	if match != domain.MatchPrefix && !ignoreCase {
+		return sq.Eq{column: value}
+	}
+
+	pattern := likeEscaper.Replace(value)
+	if match == domain.MatchPrefix {
+		pattern += "%"
+	}
+
+	if ignoreCase {
+		return sq.ILike{column: pattern}
+	}
+
+	return sq.Like{column: pattern}
+}
+
+// likeEscaper escapes LIKE wildcards, backslash is the default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func selectCars() sq.SelectBuilder {
+	return sq.Select("cars.id, cars.reg_num, cars.mark, cars.model, cars.year, cars.created_at, cars.updated_at, cars.deleted_at, cars.version, o.id, o.name, o.surname, COALESCE(o.patronymic, '')").
+		From("cars").InnerJoin("owners o on o.id = cars.ownerid")
+}
+
+func scanCar(row pgx.Row) (models.Car, error) {
+	var car models.Car
+
+	err := row.Scan(&car.ID, &car.RegNum, &car.Mark, &car.Model, &car.Year, &car.CreatedAt, &car.UpdatedAt, &car.DeletedAt, &car.Version,
+		&car.Owner.ID, &car.Owner.Name, &car.Owner.Surname, &car.Owner.Patronymic)
+	if err != nil {
+		return models.Car{}, err
+	}
+
+	return car, nil
+}
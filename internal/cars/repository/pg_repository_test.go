@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMatchText(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		match      string
+		ignoreCase bool
+		wantSQL    string
+		wantArg    string
+	}{
+		{
+			name:    "exact",
+			value:   "Ivanov",
+			wantSQL: "o.surname = ?",
+			wantArg: "Ivanov",
+		},
+		{
+			name:    "prefix",
+			value:   "Iva",
+			match:   domain.MatchPrefix,
+			wantSQL: "o.surname LIKE ?",
+			wantArg: "Iva%",
+		},
+		{
+			name:       "exact ignoring case",
+			value:      "ivanov",
+			match:      domain.MatchExact,
+			ignoreCase: true,
+			wantSQL:    "o.surname ILIKE ?",
+			wantArg:    "ivanov",
+		},
+		{
+			name:       "prefix ignoring case escapes wildcards",
+			value:      `iv_100%\`,
+			match:      domain.MatchPrefix,
+			ignoreCase: true,
+			wantSQL:    "o.surname ILIKE ?",
+			wantArg:    `iv\_100\%\\%`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := matchText("o.surname", tt.value, tt.match, tt.ignoreCase).ToSql()
+			require.NoError(t, err)
+			require.Equal(t, tt.wantSQL, sql)
+			require.Equal(t, []any{tt.wantArg}, args)
+		})
+	}
+}
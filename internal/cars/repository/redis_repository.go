@@ -55,13 +55,174 @@ func (c *CarCacheRepository) SetByIDCtx(ctx context.Context, hash string, cars m
 	return c.client.Set(ctx, c.createKey(hash), carListBytes, time.Second*time.Duration(productTTL)).Err()
 }
 
+// GetCarCount returns cached number of cars matching filters identified by hash.
+func (c *CarCacheRepository) GetCarCount(ctx context.Context, hash string) (*int, error) {
+	ctx, span := c.tracer.Start(ctx, "carRedis.GetCarCount")
+	defer span.End()
+
+	count, err := c.client.Get(ctx, c.createCountKey(hash)).Int()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &count, nil
+}
+
+// SetCarCount caches count under the car lists prefix, so it is removed by DeleteCarList.
+func (c *CarCacheRepository) SetCarCount(ctx context.Context, hash string, count int) error {
+	ctx, span := c.tracer.Start(ctx, "carRedis.SetCarCount")
+	defer span.End()
+
+	return c.client.Set(ctx, c.createCountKey(hash), count, time.Second*time.Duration(productTTL)).Err()
+}
+
+// GetCarStats returns cached stats of cars matching filters identified by hash.
+func (c *CarCacheRepository) GetCarStats(ctx context.Context, hash string) (*models.CarStats, error) {
+	ctx, span := c.tracer.Start(ctx, "carRedis.GetCarStats")
+	defer span.End()
+
+	statsBytes, err := c.client.Get(ctx, c.createStatsKey(hash)).Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var stats models.CarStats
+
+	if err = json.Unmarshal(statsBytes, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// SetCarStats caches stats under the car lists prefix, so they are removed by DeleteCarList.
+func (c *CarCacheRepository) SetCarStats(ctx context.Context, hash string, stats models.CarStats) error {
+	ctx, span := c.tracer.Start(ctx, "carRedis.SetCarStats")
+	defer span.End()
+
+	statsBytes, err := json.Marshal(stats)
+
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, c.createStatsKey(hash), statsBytes, time.Second*time.Duration(productTTL)).Err()
+}
+
+// DeleteCarList removes every cached car list. Single car entries are kept,
+// they are invalidated one by one with DeleteCar.
 func (c *CarCacheRepository) DeleteCarList(ctx context.Context) error {
 	ctx, span := c.tracer.Start(ctx, "carRedis.DeleteCarList")
 	defer span.End()
 
-	return c.client.FlushDB(ctx).Err()
+	iter := c.client.Scan(ctx, 0, c.createKey("*"), 0).Iterator()
+
+	keys := make([]string, 0)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return c.client.Unlink(ctx, keys...).Err()
+}
+
+func (c *CarCacheRepository) GetCar(ctx context.Context, carID int) (*models.Car, error) {
+	ctx, span := c.tracer.Start(ctx, "carRedis.GetCar")
+	defer span.End()
+
+	carBytes, err := c.client.Get(ctx, c.createCarKey(carID)).Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var car models.Car
+
+	if err = json.Unmarshal(carBytes, &car); err != nil {
+		return nil, err
+	}
+
+	return &car, nil
+}
+
+// GetCarByRegNum resolves regNum to car id and returns cached car with that id.
+// Entry whose reg num has changed since it was cached is treated as a miss.
+func (c *CarCacheRepository) GetCarByRegNum(ctx context.Context, regNum string) (*models.Car, error) {
+	ctx, span := c.tracer.Start(ctx, "carRedis.GetCarByRegNum")
+	defer span.End()
+
+	carID, err := c.client.Get(ctx, c.createRegNumKey(regNum)).Int()
+
+	if err != nil {
+		return nil, err
+	}
+
+	car, err := c.GetCar(ctx, carID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if car.RegNum != regNum {
+		return nil, redis.Nil
+	}
+
+	return car, nil
+}
+
+func (c *CarCacheRepository) SetCar(ctx context.Context, car models.Car) error {
+	ctx, span := c.tracer.Start(ctx, "carRedis.SetCar")
+	defer span.End()
+
+	carBytes, err := json.Marshal(car)
+
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Second * time.Duration(productTTL)
+
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, c.createCarKey(car.ID), carBytes, ttl)
+		pipe.Set(ctx, c.createRegNumKey(car.RegNum), car.ID, ttl)
+		return nil
+	})
+
+	return err
+}
+
+func (c *CarCacheRepository) DeleteCar(ctx context.Context, carID int) error {
+	ctx, span := c.tracer.Start(ctx, "carRedis.DeleteCar")
+	defer span.End()
+
+	return c.client.Del(ctx, c.createCarKey(carID)).Err()
 }
 
 func (c *CarCacheRepository) createKey(hash string) string {
 	return fmt.Sprintf("cars:%s", hash)
 }
+
+func (c *CarCacheRepository) createCountKey(hash string) string {
+	return c.createKey("count:" + hash)
+}
+
+func (c *CarCacheRepository) createStatsKey(hash string) string {
+	return c.createKey("stats:" + hash)
+}
+
+func (c *CarCacheRepository) createCarKey(carID int) string {
+	return fmt.Sprintf("car:%d", carID)
+}
+
+func (c *CarCacheRepository) createRegNumKey(regNum string) string {
+	return fmt.Sprintf("car:regnum:%s", regNum)
+}
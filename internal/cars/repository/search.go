@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"strings"
+	"unicode"
+)
+
+// rankedCar is a search result with its relevance.
+type rankedCar struct {
+	car  models.Car
+	rank float64
+}
+
+var rankedCarColumns = map[string]pagination.Column[rankedCar]{
+	"rank": {
+		Expr:  "ranked.rank",
+		Value: func(row rankedCar) any { return row.rank },
+		Dst:   func() any { return new(float64) },
+	},
+	"id": {
+		Expr:  "ranked.id",
+		Value: func(row rankedCar) any { return row.car.ID },
+		Dst:   func() any { return new(int) },
+	},
+}
+
+var searchDefaultSort = []pagination.SortKey{{Field: "rank", Desc: true}}
+
+// SearchCars finds cars whose plate, mark, model or owner matches q by words prefixes
+// or by trigram similarity, so misspelled words are found too. More relevant cars go first.
+func (c *CarRepository) SearchCars(ctx context.Context, input domain.SearchCarsRequest) (models.CarList, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.SearchCars")
+	defer span.End()
+
+	tsQuery := prefixTsQuery(input.Q)
+	if tsQuery == "" {
+		return models.CarList{}, fmt.Errorf("%w: query has no words", response.ErrInvalidRequest)
+	}
+
+	plan, err := c.searchKeyset.Plan(pagination.Request{
+		Cursor: input.Cursor,
+		Limit:  input.Limit,
+		Filter: input.Q,
+	})
+	if err != nil {
+		return models.CarList{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	// rank is computed in a subquery, so that the cursor condition can refer to it
+	query := plan.Apply(sq.Select("*").FromSelect(rankedSearch(input.Q, tsQuery), "ranked"))
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return models.CarList{}, err
+	}
+
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		return models.CarList{}, err
+	}
+
+	defer rows.Close()
+
+	found := make([]rankedCar, 0)
+
+	for rows.Next() {
+		var row rankedCar
+
+		err = rows.Scan(&row.car.ID, &row.car.RegNum, &row.car.Mark, &row.car.Model, &row.car.Year, &row.car.CreatedAt, &row.car.UpdatedAt,
+			&row.car.DeletedAt, &row.car.Version, &row.car.Owner.ID, &row.car.Owner.Name, &row.car.Owner.Surname, &row.car.Owner.Patronymic, &row.rank)
+		if err != nil {
+			return models.CarList{}, err
+		}
+
+		found = append(found, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.CarList{}, err
+	}
+
+	found, page, err := plan.Page(found)
+	if err != nil {
+		return models.CarList{}, err
+	}
+
+	cars := make([]models.Car, 0, len(found))
+	for _, row := range found {
+		cars = append(cars, row.car)
+	}
+
+	return models.CarList{
+		NextCursor: page.Next,
+		PrevCursor: page.Prev,
+		HasMore:    page.HasMore,
+		Cars:       cars,
+	}, nil
+}
+
+// rankedSearch selects cars matching q with their rank.
+func rankedSearch(q, tsQuery string) sq.SelectBuilder {
+	rank := sq.Expr(`(ts_rank(cars.search_vector || o.search_vector, to_tsquery('simple', ?))
+			+ GREATEST(word_similarity(?, cars.reg_num), word_similarity(?, cars.mark), word_similarity(?, cars.model),
+				word_similarity(?, o.name), word_similarity(?, o.surname), word_similarity(?, o.patronymic)))::float8`,
+		tsQuery, q, q, q, q, q, q)
+
+	// cars and owners are matched separately, so that each side uses its indexes. A condition
+	// spanning both tables of the join could use none of them.
+	carMatches := sq.Select("id").From("cars").Where(sq.Or{
+		sq.Expr("search_vector @@ to_tsquery('simple', ?)", tsQuery),
+		sq.Expr("? <% reg_num", q),
+		sq.Expr("? <% mark", q),
+		sq.Expr("? <% model", q),
+	})
+
+	ownerMatches := sq.Select("c.id").From("cars c").Where(sq.Expr("c.ownerid IN (?)", sq.Select("id").From("owners").Where(sq.Or{
+		sq.Expr("search_vector @@ to_tsquery('simple', ?)", tsQuery),
+		sq.Expr("? <% name", q),
+		sq.Expr("? <% surname", q),
+		sq.Expr("? <% patronymic", q),
+	})))
+
+	return sq.Select("cars.id, cars.reg_num, COALESCE(cars.mark, '') AS mark, COALESCE(cars.model, '') AS model",
+		"cars.year, cars.created_at, cars.updated_at, cars.deleted_at, cars.version",
+		"o.id AS owner_id, o.name, o.surname, COALESCE(o.patronymic, '') AS patronymic").
+		Column(sq.Alias(rank, "rank")).
+		From("cars").InnerJoin("owners o on o.id = cars.ownerid").
+		Where(sq.Eq{"cars.deleted_at": nil}).
+		Where(sq.Expr("cars.id IN (? UNION ?)", carMatches, ownerMatches))
+}
+
+// prefixTsQuery builds a tsquery matching all words of q as prefixes, e.g. "merc ben" becomes "merc:* & ben:*".
+// Everything except letters and digits is dropped, so the result is always a valid tsquery.
+func prefixTsQuery(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, strings.ToLower(word)+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPrefixTsQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		q    string
+		want string
+	}{
+		{
+			name: "single word",
+			q:    "Merc",
+			want: "merc:*",
+		},
+		{
+			name: "several words",
+			q:    "Иванов  X123",
+			want: "иванов:* & x123:*",
+		},
+		{
+			name: "tsquery syntax is dropped",
+			q:    "bmw & !(audi | lada:*)",
+			want: "bmw:* & audi:* & lada:*",
+		},
+		{
+			name: "no words",
+			q:    " &! ",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, prefixTsQuery(tt.q))
+		})
+	}
+}
+
+func TestRankedSearchMatchesTablesSeparately(t *testing.T) {
+	sql, args, err := rankedSearch("Ivanov", "ivanov:*").ToSql()
+	require.NoError(t, err)
+
+	// every match condition refers to one table, so that its indexes can be used
+	require.Contains(t, sql, "cars.id IN (SELECT id FROM cars WHERE (search_vector @@ to_tsquery('simple', ?) OR ? <% reg_num OR ? <% mark OR ? <% model) "+
+		"UNION SELECT c.id FROM cars c WHERE c.ownerid IN (SELECT id FROM owners WHERE (search_vector @@ to_tsquery('simple', ?) OR ? <% name OR ? <% surname OR ? <% patronymic)))")
+	require.Len(t, args, 15)
+}
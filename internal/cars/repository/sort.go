@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"time"
+)
+
+// carColumns are fields cars can be sorted by.
+var carColumns = map[string]pagination.Column[models.Car]{
+	"id": {
+		Expr:  "cars.id",
+		Value: func(car models.Car) any { return car.ID },
+		Dst:   func() any { return new(int) },
+	},
+	"regNum": {
+		Expr:  "cars.reg_num",
+		Value: func(car models.Car) any { return car.RegNum },
+		Dst:   func() any { return new(string) },
+	},
+	"mark": {
+		Expr:  "COALESCE(cars.mark, '')",
+		Value: func(car models.Car) any { return car.Mark },
+		Dst:   func() any { return new(string) },
+	},
+	"model": {
+		Expr:  "COALESCE(cars.model, '')",
+		Value: func(car models.Car) any { return car.Model },
+		Dst:   func() any { return new(string) },
+	},
+	"year": {
+		Expr: "COALESCE(cars.year, 0)",
+		Value: func(car models.Car) any {
+			if car.Year == nil {
+				return 0
+			}
+			return *car.Year
+		},
+		Dst: func() any { return new(int) },
+	},
+	"created_at": {
+		Expr:  "cars.created_at",
+		Value: func(car models.Car) any { return car.CreatedAt },
+		Dst:   func() any { return new(time.Time) },
+	},
+	"owner.name": {
+		Expr:  "o.name",
+		Value: func(car models.Car) any { return car.Owner.Name },
+		Dst:   func() any { return new(string) },
+	},
+	"owner.surname": {
+		Expr:  "o.surname",
+		Value: func(car models.Car) any { return car.Owner.Surname },
+		Dst:   func() any { return new(string) },
+	},
+}
+
+// carsDefaultSort keeps cars in insertion order.
+var carsDefaultSort = []pagination.SortKey{{Field: "created_at"}}
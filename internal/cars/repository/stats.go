@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+const statsDateLayout = "2006-01-02"
+
+// GetCarStats aggregates cars matching the filters of input. All aggregates are read
+// from one snapshot, so they agree with each other.
+func (c *CarRepository) GetCarStats(ctx context.Context, input domain.GetCarStatsRequest) (models.CarStats, error) {
+	ctx, span := c.tracer.Start(ctx, "carRepository.GetCarStats")
+	defer span.End()
+
+	tx, err := c.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+
+	if err != nil {
+		return models.CarStats{}, fmt.Errorf("error begin transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	filter := carsFilter(input.GetCarsRequest)
+
+	stats := models.CarStats{
+		ByMark:      make([]models.MarkCount, 0),
+		ByModel:     make([]models.ModelCount, 0),
+		ByYear:      make([]models.YearCount, 0),
+		ByDecade:    make([]models.DecadeCount, 0),
+		TopOwners:   make([]models.OwnerCount, 0),
+		AddedPerDay: make([]models.DayCount, 0),
+	}
+
+	err = queryStats(ctx, tx, statsQuery("COUNT(*)", "AVG(EXTRACT(YEAR FROM NOW() AT TIME ZONE 'utc') - cars.year)::float8").
+		Where(filter), func(rows pgx.Rows) error {
+		return rows.Scan(&stats.Total, &stats.AverageAge)
+	})
+	if err != nil {
+		return models.CarStats{}, fmt.Errorf("count cars: %w", err)
+	}
+
+	err = queryStats(ctx, tx, statsQuery("cars.mark", "COUNT(*)").
+		Where(filter).GroupBy("cars.mark").OrderBy("COUNT(*) DESC", "cars.mark"), func(rows pgx.Rows) error {
+		var row models.MarkCount
+		if err := rows.Scan(&row.Mark, &row.Count); err != nil {
+			return err
+		}
+
+		stats.ByMark = append(stats.ByMark, row)
+		return nil
+	})
+	if err != nil {
+		return models.CarStats{}, fmt.Errorf("count cars by mark: %w", err)
+	}
+
+	err = queryStats(ctx, tx, statsQuery("cars.mark", "cars.model", "COUNT(*)").
+		Where(filter).GroupBy("cars.mark", "cars.model").OrderBy("COUNT(*) DESC", "cars.mark", "cars.model"), func(rows pgx.Rows) error {
+		var row models.ModelCount
+		if err := rows.Scan(&row.Mark, &row.Model, &row.Count); err != nil {
+			return err
+		}
+
+		stats.ByModel = append(stats.ByModel, row)
+		return nil
+	})
+	if err != nil {
+		return models.CarStats{}, fmt.Errorf("count cars by model: %w", err)
+	}
+
+	err = queryStats(ctx, tx, statsQuery("cars.year", "COUNT(*)").
+		Where(filter).Where("cars.year IS NOT NULL").GroupBy("cars.year").OrderBy("cars.year"), func(rows pgx.Rows) error {
+		var row models.YearCount
+		if err := rows.Scan(&row.Year, &row.Count); err != nil {
+			return err
+		}
+
+		stats.ByYear = append(stats.ByYear, row)
+		return nil
+	})
+	if err != nil {
+		return models.CarStats{}, fmt.Errorf("count cars by year: %w", err)
+	}
+
+	// decades are built from years, so they are not queried separately
+	for _, year := range stats.ByYear {
+		decade := year.Year - year.Year%10
+
+		if n := len(stats.ByDecade); n > 0 && stats.ByDecade[n-1].Decade == decade {
+			stats.ByDecade[n-1].Count += year.Count
+			continue
+		}
+
+		stats.ByDecade = append(stats.ByDecade, models.DecadeCount{Decade: decade, Count: year.Count})
+	}
+
+	err = queryStats(ctx, tx, statsQuery("o.id", "o.name", "o.surname", "COALESCE(o.patronymic, '')", "COUNT(*)").
+		Where(filter).GroupBy("o.id").OrderBy("COUNT(*) DESC", "o.id").Limit(uint64(input.TopOwners)), func(rows pgx.Rows) error {
+		var row models.OwnerCount
+		if err := rows.Scan(&row.Owner.ID, &row.Owner.Name, &row.Owner.Surname, &row.Owner.Patronymic, &row.Count); err != nil {
+			return err
+		}
+
+		stats.TopOwners = append(stats.TopOwners, row)
+		return nil
+	})
+	if err != nil {
+		return models.CarStats{}, fmt.Errorf("count cars by owner: %w", err)
+	}
+
+	perDay := make(map[string]int)
+
+	err = queryStats(ctx, tx, statsQuery("to_char(date_trunc('day', cars.created_at), 'YYYY-MM-DD')", "COUNT(*)").
+		Where(filter).
+		Where(sq.GtOrEq{"cars.created_at": input.From}).
+		Where(sq.Lt{"cars.created_at": input.To.AddDate(0, 0, 1)}).
+		GroupBy("1"), func(rows pgx.Rows) error {
+		var (
+			day   string
+			count int
+		)
+		if err := rows.Scan(&day, &count); err != nil {
+			return err
+		}
+
+		perDay[day] = count
+		return nil
+	})
+	if err != nil {
+		return models.CarStats{}, fmt.Errorf("count cars per day: %w", err)
+	}
+
+	stats.AddedPerDay = fillDays(input.From, input.To, perDay)
+
+	return stats, tx.Commit(ctx)
+}
+
+func statsQuery(columns ...string) sq.SelectBuilder {
+	return sq.Select(columns...).From("cars").InnerJoin("owners o on o.id = cars.ownerid")
+}
+
+func queryStats(ctx context.Context, tx pgx.Tx, query sq.SelectBuilder, scan func(rows pgx.Rows) error) error {
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// fillDays lists every day from from to to, days without cars have zero count.
+func fillDays(from, to time.Time, counts map[string]int) []models.DayCount {
+	days := make([]models.DayCount, 0)
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(statsDateLayout)
+		days = append(days, models.DayCount{Date: date, Count: counts[date]})
+	}
+
+	return days
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFillDays(t *testing.T) {
+	from := time.Date(2026, 2, 27, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	days := fillDays(from, to, map[string]int{"2026-02-28": 3, "2026-03-02": 1})
+
+	require.Equal(t, []models.DayCount{
+		{Date: "2026-02-27", Count: 0},
+		{Date: "2026-02-28", Count: 3},
+		{Date: "2026-03-01", Count: 0},
+		{Date: "2026-03-02", Count: 1},
+	}, days)
+}
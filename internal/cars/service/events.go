@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"go.opentelemetry.io/otel/codes"
+	"sync"
+	"time"
+)
+
+const (
+	// carEventsBatch is the number of events read at once.
+	carEventsBatch = 500
+	// carEventsBuffer is the number of events a stream may fall behind before it is dropped.
+	carEventsBuffer = 1000
+	// carEventsPingInterval keeps idle streams open behind proxies.
+	carEventsPingInterval = 15 * time.Second
+	// carEventsRetryDelay is the pause before listening again after the connection failed.
+	carEventsRetryDelay = time.Second
+)
+
+// ErrStreamDropped is returned when a stream could not keep up with events. The client
+// should reconnect with the id of the last event it got.
+var ErrStreamDropped = errors.New("stream fell behind")
+
+// carEventSub is a stream subscribed to published events.
+type carEventSub struct {
+	events chan models.CarEvent
+	// dropped is closed when the stream is unsubscribed by the hub.
+	dropped chan struct{}
+}
+
+// carEventHub fans events read once per instance out to all streams.
+type carEventHub struct {
+	mu     sync.Mutex
+	subs   map[*carEventSub]struct{}
+	closed bool
+}
+
+func newCarEventHub() *carEventHub {
+	return &carEventHub{subs: make(map[*carEventSub]struct{})}
+}
+
+// subscribe returns nil after close.
+func (h *carEventHub) subscribe() *carEventSub {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+
+	sub := &carEventSub{events: make(chan models.CarEvent, carEventsBuffer), dropped: make(chan struct{})}
+	h.subs[sub] = struct{}{}
+
+	return sub
+}
+
+func (h *carEventHub) unsubscribe(sub *carEventSub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.drop(sub)
+}
+
+// publish never blocks, streams without room for events are dropped.
+func (h *carEventHub) publish(events []models.CarEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		for _, event := range events {
+			select {
+			case sub.events <- event:
+				continue
+			default:
+			}
+
+			h.drop(sub)
+			break
+		}
+	}
+}
+
+// close drops all streams and refuses new ones.
+func (h *carEventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+func (h *carEventHub) isClosed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.closed
+}
+
+// drop must be called with mu held.
+func (h *carEventHub) drop(sub *carEventSub) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+
+	delete(h.subs, sub)
+	close(sub.dropped)
+}
+
+// RunCarEvents publishes events written by any instance to streams of this one until ctx
+// is cancelled. It listens again after the connection is lost, events written meanwhile
+// are read then.
+func (s *Service) RunCarEvents(ctx context.Context) {
+	var last *models.EventPosition
+
+	for {
+		err := s.repo.ListenCarEvents(ctx, func() error {
+			// the first call only finds out where to start
+			if last == nil {
+				position, err := s.repo.CurrentCarEventPosition(ctx)
+				if err != nil {
+					return fmt.Errorf("get car event position: %w", err)
+				}
+
+				last = &position
+				return nil
+			}
+
+			return s.publishCarEvents(ctx, last)
+		})
+
+		if ctx.Err() != nil {
+			s.events.close()
+			return
+		}
+
+		s.log.Infof("cannot listen to car events: %v", err)
+
+		select {
+		case <-ctx.Done():
+			s.events.close()
+			return
+		case <-time.After(carEventsRetryDelay):
+		}
+	}
+}
+
+// StopCarEvents ends all streams, it is called when the server shuts down.
+func (s *Service) StopCarEvents() {
+	s.events.close()
+}
+
+// publishCarEvents publishes events after last and moves it to the last one published.
+func (s *Service) publishCarEvents(ctx context.Context, last *models.EventPosition) error {
+	for {
+		events, err := s.repo.GetCarEvents(ctx, *last, domain.CarEventsRequest{}, carEventsBatch)
+		if err != nil {
+			return fmt.Errorf("get car events: %w", err)
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		s.events.publish(events)
+		*last = events[len(events)-1].Position
+
+		if len(events) < carEventsBatch {
+			return nil
+		}
+	}
+}
+
+// StreamCarEvents sends events matching input to send, starting after input.LastEventID or
+// with new events without it. ping is called once the missed events were sent and then
+// whenever the stream was idle for a while. It returns when ctx is done, the server shuts
+// down, send or ping fail, or with ErrStreamDropped when the stream fell behind.
+func (s *Service) StreamCarEvents(ctx context.Context, input domain.CarEventsRequest, send func(event models.CarEvent) error,
+	ping func() error) error {
+	ctx, span := s.tracer.Start(ctx, "carService.StreamCarEvents")
+	defer span.End()
+
+	var (
+		after models.EventPosition
+		err   error
+	)
+
+	if input.LastEventID != "" {
+		after, err = models.ParseEventPosition(input.LastEventID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+		}
+	}
+
+	// events published from now on are buffered, so none are lost while missed ones are read
+	sub := s.events.subscribe()
+	if sub == nil {
+		return response.ErrUnavailable
+	}
+
+	defer s.events.unsubscribe(sub)
+
+	var last models.EventPosition
+
+	if input.LastEventID == "" {
+		last, err = s.repo.CurrentCarEventPosition(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			s.log.Infof("cannot get car event position: %v", err)
+			return fmt.Errorf("get car event position: %w", err)
+		}
+	} else {
+		last, err = s.sendMissedCarEvents(ctx, after, input, send)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			s.log.Infof("cannot send missed car events: %v", err)
+			return fmt.Errorf("send missed car events: %w", err)
+		}
+	}
+
+	if err := ping(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(carEventsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.dropped:
+			// events still buffered were published before the drop, the client gets them on reconnect
+			if s.events.isClosed() {
+				return nil
+			}
+
+			return ErrStreamDropped
+		case <-ticker.C:
+			if err := ping(); err != nil {
+				return err
+			}
+		case event := <-sub.events:
+			// events read with the missed ones are published again
+			if !last.Less(event.Position) || !carEventMatches(event, input) {
+				continue
+			}
+
+			if err := send(event); err != nil {
+				return err
+			}
+
+			last = event.Position
+			ticker.Reset(carEventsPingInterval)
+		}
+	}
+}
+
+// sendMissedCarEvents sends events after last from the database and returns the position of the last one sent.
+func (s *Service) sendMissedCarEvents(ctx context.Context, last models.EventPosition, input domain.CarEventsRequest,
+	send func(event models.CarEvent) error) (models.EventPosition, error) {
+	for {
+		events, err := s.repo.GetCarEvents(ctx, last, input, carEventsBatch)
+		if err != nil {
+			return last, err
+		}
+
+		for _, event := range events {
+			if err = send(event); err != nil {
+				return last, err
+			}
+
+			last = event.Position
+		}
+
+		if len(events) < carEventsBatch {
+			return last, nil
+		}
+	}
+}
+
+// carEventMatches reports whether the car of event matches filters of input before or after the change.
+func carEventMatches(event models.CarEvent, input domain.CarEventsRequest) bool {
+	if input.Mark != "" && !equalTo(event.Mark, input.Mark) && !equalTo(event.PreviousMark, input.Mark) {
+		return false
+	}
+
+	if input.OwnerID != 0 && !equalTo(event.OwnerID, input.OwnerID) && !equalTo(event.PreviousOwnerID, input.OwnerID) {
+		return false
+	}
+
+	return true
+}
+
+func equalTo[T comparable](p *T, value T) bool {
+	return p != nil && *p == value
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	repoMock "github.com/Verce11o/effective-mobile-test/internal/cars/service/mocks"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func carEvent(tx int64, id int, mark string) models.CarEvent {
+	return models.CarEvent{
+		Type:       models.CarEventUpdated,
+		AuditEntry: models.AuditEntry{ID: id},
+		Position:   models.EventPosition{Tx: tx, ID: id},
+		Mark:       &mark,
+	}
+}
+
+func TestCarEventMatches(t *testing.T) {
+	lada, bmw := "Lada", "BMW"
+	ownerID, previousOwnerID := 1, 2
+
+	tests := []struct {
+		name  string
+		event models.CarEvent
+		input domain.CarEventsRequest
+		want  bool
+	}{
+		{name: "No filters", event: models.CarEvent{}, want: true},
+		{name: "Mark", event: models.CarEvent{Mark: &lada}, input: domain.CarEventsRequest{Mark: "Lada"}, want: true},
+		{name: "Other mark", event: models.CarEvent{Mark: &bmw}, input: domain.CarEventsRequest{Mark: "Lada"}, want: false},
+		{name: "Previous mark", event: models.CarEvent{Mark: &bmw, PreviousMark: &lada}, input: domain.CarEventsRequest{Mark: "Lada"}, want: true},
+		{name: "No mark", event: models.CarEvent{}, input: domain.CarEventsRequest{Mark: "Lada"}, want: false},
+		{name: "Owner", event: models.CarEvent{OwnerID: &ownerID}, input: domain.CarEventsRequest{OwnerID: 1}, want: true},
+		{
+			name:  "Previous owner",
+			event: models.CarEvent{OwnerID: &ownerID, PreviousOwnerID: &previousOwnerID},
+			input: domain.CarEventsRequest{OwnerID: 2},
+			want:  true,
+		},
+		{
+			name:  "Mark of another owner",
+			event: models.CarEvent{Mark: &lada, OwnerID: &ownerID},
+			input: domain.CarEventsRequest{Mark: "Lada", OwnerID: 2},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, carEventMatches(tt.event, tt.input))
+		})
+	}
+}
+
+func TestService_StreamCarEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := repoMock.NewRepository(t)
+
+	input := domain.CarEventsRequest{Mark: "Lada", LastEventID: "100-10"}
+
+	// 9 was committed after 11, ids of the feed follow commits
+	repo.On("GetCarEvents", mock.Anything, models.EventPosition{Tx: 100, ID: 10}, input, carEventsBatch).
+		Return([]models.CarEvent{carEvent(101, 11, "Lada"), carEvent(102, 9, "Lada")}, nil).Once()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		tracer: tracer.InitTracer(ctx, "", ""),
+		events: newCarEventHub(),
+	}
+
+	sent := make([]int, 0)
+	live := make(chan struct{})
+
+	go func() {
+		<-live
+		// 9 was sent with the missed events already and 13 does not match
+		s.events.publish([]models.CarEvent{carEvent(102, 9, "Lada"), carEvent(103, 13, "BMW"), carEvent(103, 14, "Lada")})
+	}()
+
+	err := s.StreamCarEvents(ctx, input, func(event models.CarEvent) error {
+		sent = append(sent, event.ID)
+		if event.ID == 14 {
+			cancel()
+		}
+
+		return nil
+	}, func() error {
+		close(live)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int{11, 9, 14}, sent)
+}
+
+func TestService_StreamCarEventsInvalidID(t *testing.T) {
+	ctx := context.Background()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repoMock.NewRepository(t),
+		tracer: tracer.InitTracer(ctx, "", ""),
+		events: newCarEventHub(),
+	}
+
+	for _, id := range []string{"10", "a-1", "1-b", "-1-2"} {
+		err := s.StreamCarEvents(ctx, domain.CarEventsRequest{LastEventID: id}, nil, nil)
+		require.ErrorIs(t, err, response.ErrInvalidRequest, id)
+	}
+}
+
+func TestService_StreamCarEventsFromNow(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	repo.On("CurrentCarEventPosition", mock.Anything).Return(models.EventPosition{Tx: 200}, nil).Once()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		tracer: tracer.InitTracer(ctx, "", ""),
+		events: newCarEventHub(),
+	}
+
+	sent := make([]int, 0)
+
+	err := s.StreamCarEvents(ctx, domain.CarEventsRequest{}, func(event models.CarEvent) error {
+		sent = append(sent, event.ID)
+		s.StopCarEvents()
+
+		return nil
+	}, func() error {
+		go s.events.publish([]models.CarEvent{carEvent(199, 20, "Lada"), carEvent(200, 21, "Lada")})
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []int{21}, sent)
+
+	err = s.StreamCarEvents(ctx, domain.CarEventsRequest{}, nil, nil)
+	require.ErrorIs(t, err, response.ErrUnavailable)
+}
+
+func TestCarEventHub_DropsSlowStreams(t *testing.T) {
+	hub := newCarEventHub()
+
+	slow := hub.subscribe()
+	fast := hub.subscribe()
+
+	events := make([]models.CarEvent, carEventsBuffer)
+	for i := range events {
+		events[i] = carEvent(1, i+1, "Lada")
+	}
+
+	hub.publish(events)
+
+	for range events {
+		<-fast.events
+	}
+
+	hub.publish([]models.CarEvent{carEvent(1, carEventsBuffer+1, "Lada")})
+
+	select {
+	case <-slow.dropped:
+	case <-time.After(time.Second):
+		t.Fatal("slow stream was not dropped")
+	}
+
+	select {
+	case <-fast.dropped:
+		t.Fatal("fast stream was dropped")
+	default:
+	}
+
+	require.Equal(t, carEventsBuffer+1, (<-fast.events).ID)
+}
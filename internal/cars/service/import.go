@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"go.opentelemetry.io/otel/codes"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// importBatchSize is the number of valid rows enriched and inserted together.
+const importBatchSize = 100
+
+// importColumns are columns an import file may have, named like in exports. Other columns are ignored.
+var importColumns = []string{"regNum", "mark", "model", "year", "owner_name", "owner_surname", "owner_patronymic"}
+
+// importRow is a valid row waiting for its batch.
+type importRow struct {
+	line int
+	car  domain.Car
+	// plain rows only have a plate, the rest is looked up in the external api
+	plain bool
+}
+
+// ImportCars creates cars from csv rows. A row is either a plain plate, which is enriched
+// like in CreateCar, or a fully specified car. Invalid rows and rows that cannot be created
+// are reported, valid rows are inserted in batches. When the import stops after a batch was
+// created, the report is truncated instead of failing, so the client still learns which rows
+// were created.
+func (s *Service) ImportCars(ctx context.Context, file io.Reader) (models.ImportResult, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.ImportCars")
+	defer span.End()
+
+	result, err := s.importCars(ctx, file)
+
+	if result.Created > 0 {
+		if err := s.cache.DeleteCarList(ctx); err != nil {
+			s.log.Infof("cannot clear cache: %v", err)
+		}
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot import cars: %v", err)
+
+		if result.Created == 0 {
+			return models.ImportResult{}, fmt.Errorf("import cars: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// importCars returns the result so far along with the error that stopped the import.
+func (s *Service) importCars(ctx context.Context, file io.Reader) (models.ImportResult, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return models.ImportResult{}, fmt.Errorf("%w: file is empty", response.ErrInvalidRequest)
+	}
+
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	columns, err := importHeader(header)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	result := models.ImportResult{
+		Errors: make([]models.ImportRowError, 0),
+	}
+
+	// lines of plates seen so far, a plate can only be imported once
+	seen := make(map[string]int)
+	batch := make([]importRow, 0, importBatchSize)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			// rows of the pending batch are not created, the file cannot be read past the error
+			rejectRows(&result, batch, importStoppedReason)
+
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result.Rows++
+				rejectRow(&result, parseErr.StartLine, "", parseErr.Err.Error())
+			}
+
+			return truncateImport(result), fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		result.Rows++
+
+		row, err := parseImportRow(columns, record)
+		if err != nil {
+			rejectRow(&result, line, row.car.RegNum, err.Error())
+			continue
+		}
+
+		if first, ok := seen[row.car.RegNum]; ok {
+			rejectRow(&result, line, row.car.RegNum, fmt.Sprintf("duplicate of row %d", first))
+			continue
+		}
+
+		seen[row.car.RegNum] = line
+		row.line = line
+		batch = append(batch, row)
+
+		if len(batch) == importBatchSize {
+			if err = s.importBatch(ctx, batch, &result); err != nil {
+				return truncateImport(result), err
+			}
+
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err = s.importBatch(ctx, batch, &result); err != nil {
+			return truncateImport(result), err
+		}
+	}
+
+	return finishImport(result), nil
+}
+
+// importStoppedReason is reported for valid rows that were not created because the import stopped.
+const importStoppedReason = "not imported, the import stopped"
+
+// truncateImport finishes the result of an import that stopped before the end of the file.
+func truncateImport(result models.ImportResult) models.ImportResult {
+	result.Truncated = true
+	return finishImport(result)
+}
+
+func finishImport(result models.ImportResult) models.ImportResult {
+	// rows rejected by batches are reported after rows rejected while reading
+	slices.SortStableFunc(result.Errors, func(a, b models.ImportRowError) int {
+		return a.Row - b.Row
+	})
+
+	result.Rejected = len(result.Errors)
+
+	return result
+}
+
+// importBatch enriches plain rows of batch and creates its cars, rows that fail are rejected.
+// When the batch fails, all its rows that were not rejected yet are rejected as not imported.
+func (s *Service) importBatch(ctx context.Context, batch []importRow, result *models.ImportResult) error {
+	regNums := make([]string, 0, len(batch))
+	for _, row := range batch {
+		if row.plain {
+			regNums = append(regNums, row.car.RegNum)
+		}
+	}
+
+	enriched := make([]domain.Car, 0)
+	failed := make(map[string]models.PlateResult)
+
+	if len(regNums) > 0 {
+		var err error
+
+		enriched, failed, err = s.enrichCars(ctx, regNums, true)
+		if err != nil {
+			rejectRows(result, batch, importStoppedReason)
+			return fmt.Errorf("get car info: %w", err)
+		}
+	}
+
+	cars := make([]domain.Car, 0, len(batch))
+	pending := make([]importRow, 0, len(batch))
+	lines := make(map[string]int, len(batch))
+
+	for _, row := range batch {
+		if !row.plain {
+			cars = append(cars, row.car)
+			pending = append(pending, row)
+			lines[row.car.RegNum] = row.line
+			continue
+		}
+
+		if plate, ok := failed[row.car.RegNum]; ok {
+			rejectRow(result, row.line, row.car.RegNum, plate.Error)
+			continue
+		}
+
+		// enriched cars keep the order of plates they were looked up for
+		car := enriched[0]
+		enriched = enriched[1:]
+
+		car.RegNum = row.car.RegNum
+		cars = append(cars, car)
+		pending = append(pending, row)
+		lines[car.RegNum] = row.line
+	}
+
+	if len(cars) == 0 {
+		return nil
+	}
+
+	created, err := s.repo.CreateCars(ctx, cars, domain.OnConflictSkip)
+	if err != nil {
+		rejectRows(result, pending, importStoppedReason)
+		return fmt.Errorf("create cars: %w", err)
+	}
+
+	result.Created += len(created.Created)
+
+	for _, regNum := range created.Skipped {
+		rejectRow(result, lines[regNum], regNum, "car already exists")
+	}
+
+	return nil
+}
+
+func rejectRow(result *models.ImportResult, line int, regNum, reason string) {
+	result.Errors = append(result.Errors, models.ImportRowError{Row: line, RegNum: regNum, Reason: reason})
+}
+
+func rejectRows(result *models.ImportResult, rows []importRow, reason string) {
+	for _, row := range rows {
+		rejectRow(result, row.line, row.car.RegNum, reason)
+	}
+}
+
+// importHeader maps import columns to their positions, regNum is required.
+func importHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+
+		for _, column := range importColumns {
+			if name == column {
+				columns[column] = i
+			}
+		}
+	}
+
+	if _, ok := columns["regNum"]; !ok {
+		return nil, errors.New("header has no regNum column")
+	}
+
+	return columns, nil
+}
+
+// parseImportRow validates record. The returned row has the plate even when record is invalid.
+func parseImportRow(columns map[string]int, record []string) (importRow, error) {
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	row := importRow{
+		car: domain.Car{
+			RegNum: value("regNum"),
+			Mark:   value("mark"),
+			Model:  value("model"),
+			Owner: domain.People{
+				Name:    value("owner_name"),
+				Surname: value("owner_surname"),
+			},
+		},
+	}
+
+	if row.car.RegNum == "" {
+		return row, errors.New("regNum is required")
+	}
+
+	year := value("year")
+	patronymic := value("owner_patronymic")
+
+	if row.car.Mark == "" && row.car.Model == "" && year == "" &&
+		row.car.Owner.Name == "" && row.car.Owner.Surname == "" && patronymic == "" {
+		row.plain = true
+		return row, nil
+	}
+
+	missing := make([]string, 0)
+	for _, column := range []string{"mark", "model", "owner_name", "owner_surname"} {
+		if value(column) == "" {
+			missing = append(missing, column)
+		}
+	}
+
+	if len(missing) > 0 {
+		return row, fmt.Errorf("%s required for a car that is not a plain plate", strings.Join(missing, ", "))
+	}
+
+	if year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			return row, fmt.Errorf("year %q is not a number", year)
+		}
+
+		if !validYear(y) {
+			return row, fmt.Errorf("year %d is out of range", y)
+		}
+
+		row.car.Year = &y
+	}
+
+	if patronymic != "" {
+		row.car.Owner.Patronymic = &patronymic
+	}
+
+	return row, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	repoMock "github.com/Verce11o/effective-mobile-test/internal/cars/service/mocks"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestService_ImportCars(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+	communicator := repoMock.NewApiCommunicator(t)
+
+	communicator.On("GetCarInfo", mock.Anything, "J623FP555").
+		Return(domain.Car{RegNum: "J623FP555", Mark: "Lada", Model: "Vesta"}, nil).Once()
+	communicator.On("GetCarInfo", mock.Anything, "A000AA000").Return(domain.Car{}, response.ErrCarInfoNotFound).Once()
+
+	year := 2018
+	patronymic := "Petrovich"
+
+	repo.On("CreateCars", mock.Anything, []domain.Car{
+		{RegNum: "X123XX150", Mark: "BMW", Model: "X5", Year: &year,
+			Owner: domain.People{Name: "Ivan", Surname: "Ivanov", Patronymic: &patronymic}},
+		{RegNum: "J623FP555", Mark: "Lada", Model: "Vesta"},
+		{RegNum: "Z407GI541", Mark: "Audi", Model: "A4", Owner: domain.People{Name: "Petr", Surname: "Petrov"}},
+	}, domain.OnConflictSkip).Return(models.CreateCarsResult{
+		Created: []models.Car{{ID: 1, RegNum: "X123XX150"}, {ID: 2, RegNum: "J623FP555"}},
+		Skipped: []string{"Z407GI541"},
+	}, nil).Once()
+	cache.On("DeleteCarList", mock.Anything).Return(nil).Once()
+
+	s := &Service{
+		log:          logger.NewMockLogger(),
+		repo:         repo,
+		cache:        cache,
+		tracer:       tracer.InitTracer(ctx, "", ""),
+		communicator: communicator,
+		enrich:       EnrichOptions{Concurrency: 2},
+	}
+
+	file := "\ufeffregNum,mark,model,year,owner_name,owner_surname,owner_patronymic,color\n" +
+		"X123XX150,BMW,X5,2018,Ivan,Ivanov,Petrovich,black\n" +
+		"J623FP555\n" +
+		"A000AA000,,,,,,\n" +
+		",BMW,X5,2018,Ivan,Ivanov,,\n" +
+		"B111BB111,BMW,,,Ivan,,,\n" +
+		"C222CC222,BMW,X5,old,Ivan,Ivanov,,\n" +
+		"J623FP555\n" +
+		"Z407GI541,Audi,A4,,Petr,Petrov,,\n"
+
+	result, err := s.ImportCars(ctx, strings.NewReader(file))
+	require.NoError(t, err)
+
+	require.Equal(t, 8, result.Rows)
+	require.Equal(t, 2, result.Created)
+	require.Equal(t, 6, result.Rejected)
+	require.Equal(t, []models.ImportRowError{
+		{Row: 4, RegNum: "A000AA000", Reason: "car not found"},
+		{Row: 5, Reason: "regNum is required"},
+		{Row: 6, RegNum: "B111BB111", Reason: "model, owner_surname required for a car that is not a plain plate"},
+		{Row: 7, RegNum: "C222CC222", Reason: `year "old" is not a number`},
+		{Row: 8, RegNum: "J623FP555", Reason: "duplicate of row 3"},
+		{Row: 9, RegNum: "Z407GI541", Reason: "car already exists"},
+	}, result.Errors)
+}
+
+func TestService_ImportCarsInvalidFile(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{
+			name: "empty file",
+		},
+		{
+			name: "no regNum column",
+			file: "plate,mark\nX123XX150,BMW\n",
+		},
+		{
+			name: "broken quotes",
+			file: "regNum\n\"X123XX150\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			s := &Service{
+				log:    logger.NewMockLogger(),
+				tracer: tracer.InitTracer(ctx, "", ""),
+			}
+
+			_, err := s.ImportCars(ctx, strings.NewReader(tt.file))
+			require.ErrorIs(t, err, response.ErrInvalidRequest)
+		})
+	}
+}
+
+func TestService_ImportCarsRepositoryError(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+
+	repoErr := errors.New("connection refused")
+	repo.On("CreateCars", mock.Anything, mock.Anything, domain.OnConflictSkip).
+		Return(models.CreateCarsResult{}, repoErr).Once()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		tracer: tracer.InitTracer(ctx, "", ""),
+	}
+
+	_, err := s.ImportCars(ctx, strings.NewReader("regNum,mark,model,owner_name,owner_surname\nX123XX150,BMW,X5,Ivan,Ivanov\n"))
+	require.ErrorIs(t, err, repoErr)
+}
+
+func TestService_ImportCarsStopsAfterBatch(t *testing.T) {
+	// the first batch is full, so it is created before the rest of the file is read
+	var file strings.Builder
+	file.WriteString("regNum,mark,model,owner_name,owner_surname\n")
+
+	for i := 0; i < importBatchSize+1; i++ {
+		file.WriteString("X" + strconv.Itoa(i) + ",BMW,X5,Ivan,Ivanov\n")
+	}
+
+	repoErr := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		file       string
+		secondErr  error
+		wantRows   int
+		wantErrors []models.ImportRowError
+	}{
+		{
+			name:      "second batch fails",
+			file:      file.String(),
+			secondErr: repoErr,
+			wantRows:  importBatchSize + 1,
+			wantErrors: []models.ImportRowError{
+				{Row: importBatchSize + 2, RegNum: "X100", Reason: importStoppedReason},
+			},
+		},
+		{
+			name:     "broken line after the first batch",
+			file:     file.String() + "Y1,BMW,X5,Ivan,Ivanov\n\"Y2\n",
+			wantRows: importBatchSize + 3,
+			wantErrors: []models.ImportRowError{
+				{Row: importBatchSize + 2, RegNum: "X100", Reason: importStoppedReason},
+				{Row: importBatchSize + 3, RegNum: "Y1", Reason: importStoppedReason},
+				{Row: importBatchSize + 4, Reason: "extraneous or missing \" in quoted-field"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+
+			created := make([]models.Car, importBatchSize)
+			for i := range created {
+				created[i] = models.Car{ID: i + 1, RegNum: "X" + strconv.Itoa(i)}
+			}
+
+			repo.On("CreateCars", mock.Anything, mock.Anything, domain.OnConflictSkip).
+				Return(models.CreateCarsResult{Created: created}, nil).Once()
+
+			if tt.secondErr != nil {
+				repo.On("CreateCars", mock.Anything, mock.Anything, domain.OnConflictSkip).
+					Return(models.CreateCarsResult{}, tt.secondErr).Once()
+			}
+
+			// cached lists are stale although the import did not finish
+			cache.On("DeleteCarList", mock.Anything).Return(nil).Once()
+
+			s := &Service{
+				log:    logger.NewMockLogger(),
+				repo:   repo,
+				cache:  cache,
+				tracer: tracer.InitTracer(ctx, "", ""),
+			}
+
+			result, err := s.ImportCars(ctx, strings.NewReader(tt.file))
+			require.NoError(t, err)
+
+			require.True(t, result.Truncated)
+			require.Equal(t, tt.wantRows, result.Rows)
+			require.Equal(t, importBatchSize, result.Created)
+			require.Equal(t, len(tt.wantErrors), result.Rejected)
+			require.Equal(t, tt.wantErrors, result.Errors)
+		})
+	}
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/Verce11o/effective-mobile-test/internal/domain"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,9 +14,9 @@ type ApiCommunicator struct {
 	mock.Mock
 }
 
-// GetCarInfo provides a mock function with given fields: regNum
-func (_m *ApiCommunicator) GetCarInfo(regNum string) (domain.Car, error) {
-	ret := _m.Called(regNum)
+// GetCarInfo provides a mock function with given fields: ctx, regNum
+func (_m *ApiCommunicator) GetCarInfo(ctx context.Context, regNum string) (domain.Car, error) {
+	ret := _m.Called(ctx, regNum)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCarInfo")
@@ -22,17 +24,17 @@ func (_m *ApiCommunicator) GetCarInfo(regNum string) (domain.Car, error) {
 
 	var r0 domain.Car
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (domain.Car, error)); ok {
-		return rf(regNum)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.Car, error)); ok {
+		return rf(ctx, regNum)
 	}
-	if rf, ok := ret.Get(0).(func(string) domain.Car); ok {
-		r0 = rf(regNum)
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.Car); ok {
+		r0 = rf(ctx, regNum)
 	} else {
 		r0 = ret.Get(0).(domain.Car)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(regNum)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, regNum)
 	} else {
 		r1 = ret.Error(1)
 	}
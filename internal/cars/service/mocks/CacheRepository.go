@@ -14,6 +14,24 @@ type CacheRepository struct {
 	mock.Mock
 }
 
+// DeleteCar provides a mock function with given fields: ctx, carID
+func (_m *CacheRepository) DeleteCar(ctx context.Context, carID int) error {
+	ret := _m.Called(ctx, carID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, carID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteCarList provides a mock function with given fields: ctx
 func (_m *CacheRepository) DeleteCarList(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -32,6 +50,96 @@ func (_m *CacheRepository) DeleteCarList(ctx context.Context) error {
 	return r0
 }
 
+// GetCar provides a mock function with given fields: ctx, carID
+func (_m *CacheRepository) GetCar(ctx context.Context, carID int) (*models.Car, error) {
+	ret := _m.Called(ctx, carID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCar")
+	}
+
+	var r0 *models.Car
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Car, error)); ok {
+		return rf(ctx, carID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Car); ok {
+		r0 = rf(ctx, carID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Car)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, carID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarByRegNum provides a mock function with given fields: ctx, regNum
+func (_m *CacheRepository) GetCarByRegNum(ctx context.Context, regNum string) (*models.Car, error) {
+	ret := _m.Called(ctx, regNum)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarByRegNum")
+	}
+
+	var r0 *models.Car
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Car, error)); ok {
+		return rf(ctx, regNum)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Car); ok {
+		r0 = rf(ctx, regNum)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Car)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, regNum)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarCount provides a mock function with given fields: ctx, hash
+func (_m *CacheRepository) GetCarCount(ctx context.Context, hash string) (*int, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarCount")
+	}
+
+	var r0 *int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*int, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *int); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCarList provides a mock function with given fields: ctx, hash
 func (_m *CacheRepository) GetCarList(ctx context.Context, hash string) (*models.CarList, error) {
 	ret := _m.Called(ctx, hash)
@@ -62,6 +170,36 @@ func (_m *CacheRepository) GetCarList(ctx context.Context, hash string) (*models
 	return r0, r1
 }
 
+// GetCarStats provides a mock function with given fields: ctx, hash
+func (_m *CacheRepository) GetCarStats(ctx context.Context, hash string) (*models.CarStats, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarStats")
+	}
+
+	var r0 *models.CarStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.CarStats, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.CarStats); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.CarStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetByIDCtx provides a mock function with given fields: ctx, cursor, cars
 func (_m *CacheRepository) SetByIDCtx(ctx context.Context, cursor string, cars models.CarList) error {
 	ret := _m.Called(ctx, cursor, cars)
@@ -80,6 +218,60 @@ func (_m *CacheRepository) SetByIDCtx(ctx context.Context, cursor string, cars m
 	return r0
 }
 
+// SetCar provides a mock function with given fields: ctx, car
+func (_m *CacheRepository) SetCar(ctx context.Context, car models.Car) error {
+	ret := _m.Called(ctx, car)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Car) error); ok {
+		r0 = rf(ctx, car)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetCarCount provides a mock function with given fields: ctx, hash, count
+func (_m *CacheRepository) SetCarCount(ctx context.Context, hash string, count int) error {
+	ret := _m.Called(ctx, hash, count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCarCount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, hash, count)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetCarStats provides a mock function with given fields: ctx, hash, stats
+func (_m *CacheRepository) SetCarStats(ctx context.Context, hash string, stats models.CarStats) error {
+	ret := _m.Called(ctx, hash, stats)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCarStats")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.CarStats) error); ok {
+		r0 = rf(ctx, hash, stats)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewCacheRepository creates a new instance of CacheRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCacheRepository(t interface {
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CarInfoCache is an autogenerated mock type for the CarInfoCache type
+type CarInfoCache struct {
+	mock.Mock
+}
+
+// Evict provides a mock function with given fields: ctx, regNum
+func (_m *CarInfoCache) Evict(ctx context.Context, regNum string) error {
+	ret := _m.Called(ctx, regNum)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Evict")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, regNum)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCarInfoCache creates a new instance of CarInfoCache. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCarInfoCache(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CarInfoCache {
+	mock := &CarInfoCache{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -9,6 +9,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	models "github.com/Verce11o/effective-mobile-test/internal/models"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -16,17 +18,101 @@ type Repository struct {
 	mock.Mock
 }
 
-// CreateCars provides a mock function with given fields: ctx, cars
-func (_m *Repository) CreateCars(ctx context.Context, cars []domain.Car) error {
-	ret := _m.Called(ctx, cars)
+// CountCars provides a mock function with given fields: ctx, input, estimate
+func (_m *Repository) CountCars(ctx context.Context, input domain.GetCarsRequest, estimate bool) (int, error) {
+	ret := _m.Called(ctx, input, estimate)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountCars")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarsRequest, bool) (int, error)); ok {
+		return rf(ctx, input, estimate)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarsRequest, bool) int); ok {
+		r0 = rf(ctx, input, estimate)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.GetCarsRequest, bool) error); ok {
+		r1 = rf(ctx, input, estimate)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCars provides a mock function with given fields: ctx, cars, onConflict
+func (_m *Repository) CreateCars(ctx context.Context, cars []domain.Car, onConflict string) (models.CreateCarsResult, error) {
+	ret := _m.Called(ctx, cars, onConflict)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateCars")
 	}
 
+	var r0 models.CreateCarsResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Car, string) (models.CreateCarsResult, error)); ok {
+		return rf(ctx, cars, onConflict)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Car, string) models.CreateCarsResult); ok {
+		r0 = rf(ctx, cars, onConflict)
+	} else {
+		r0 = ret.Get(0).(models.CreateCarsResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.Car, string) error); ok {
+		r1 = rf(ctx, cars, onConflict)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CurrentCarEventPosition provides a mock function with given fields: ctx
+func (_m *Repository) CurrentCarEventPosition(ctx context.Context) (models.EventPosition, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CurrentCarEventPosition")
+	}
+
+	var r0 models.EventPosition
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (models.EventPosition, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) models.EventPosition); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(models.EventPosition)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCar provides a mock function with given fields: ctx, carID, version
+func (_m *Repository) DeleteCar(ctx context.Context, carID int, version *int) error {
+	ret := _m.Called(ctx, carID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCar")
+	}
+
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, []domain.Car) error); ok {
-		r0 = rf(ctx, cars)
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int) error); ok {
+		r0 = rf(ctx, carID, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -34,17 +120,17 @@ func (_m *Repository) CreateCars(ctx context.Context, cars []domain.Car) error {
 	return r0
 }
 
-// DeleteCar provides a mock function with given fields: ctx, carID
-func (_m *Repository) DeleteCar(ctx context.Context, carID int) error {
-	ret := _m.Called(ctx, carID)
+// ExportCars provides a mock function with given fields: ctx, input, fn
+func (_m *Repository) ExportCars(ctx context.Context, input domain.GetCarsRequest, fn func(models.Car) error) error {
+	ret := _m.Called(ctx, input, fn)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteCar")
+		panic("no return value specified for ExportCars")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, carID)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarsRequest, func(models.Car) error) error); ok {
+		r0 = rf(ctx, input, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -52,6 +138,178 @@ func (_m *Repository) DeleteCar(ctx context.Context, carID int) error {
 	return r0
 }
 
+// GetCar provides a mock function with given fields: ctx, carID
+func (_m *Repository) GetCar(ctx context.Context, carID int) (models.Car, error) {
+	ret := _m.Called(ctx, carID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCar")
+	}
+
+	var r0 models.Car
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Car, error)); ok {
+		return rf(ctx, carID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Car); ok {
+		r0 = rf(ctx, carID)
+	} else {
+		r0 = ret.Get(0).(models.Car)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, carID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarByRegNum provides a mock function with given fields: ctx, regNum
+func (_m *Repository) GetCarByRegNum(ctx context.Context, regNum string) (models.Car, error) {
+	ret := _m.Called(ctx, regNum)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarByRegNum")
+	}
+
+	var r0 models.Car
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.Car, error)); ok {
+		return rf(ctx, regNum)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Car); ok {
+		r0 = rf(ctx, regNum)
+	} else {
+		r0 = ret.Get(0).(models.Car)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, regNum)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarEvents provides a mock function with given fields: ctx, after, input, limit
+func (_m *Repository) GetCarEvents(ctx context.Context, after models.EventPosition, input domain.CarEventsRequest, limit int) ([]models.CarEvent, error) {
+	ret := _m.Called(ctx, after, input, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarEvents")
+	}
+
+	var r0 []models.CarEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.EventPosition, domain.CarEventsRequest, int) ([]models.CarEvent, error)); ok {
+		return rf(ctx, after, input, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.EventPosition, domain.CarEventsRequest, int) []models.CarEvent); ok {
+		r0 = rf(ctx, after, input, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CarEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.EventPosition, domain.CarEventsRequest, int) error); ok {
+		r1 = rf(ctx, after, input, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarHistory provides a mock function with given fields: ctx, carID, input
+func (_m *Repository) GetCarHistory(ctx context.Context, carID int, input domain.GetCarHistoryRequest) (models.AuditList, error) {
+	ret := _m.Called(ctx, carID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarHistory")
+	}
+
+	var r0 models.AuditList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarHistoryRequest) (models.AuditList, error)); ok {
+		return rf(ctx, carID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarHistoryRequest) models.AuditList); ok {
+		r0 = rf(ctx, carID, input)
+	} else {
+		r0 = ret.Get(0).(models.AuditList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.GetCarHistoryRequest) error); ok {
+		r1 = rf(ctx, carID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarOwners provides a mock function with given fields: ctx, carID, input
+func (_m *Repository) GetCarOwners(ctx context.Context, carID int, input domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error) {
+	ret := _m.Called(ctx, carID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarOwners")
+	}
+
+	var r0 []models.OwnershipRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error)); ok {
+		return rf(ctx, carID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetCarOwnersRequest) []models.OwnershipRecord); ok {
+		r0 = rf(ctx, carID, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.OwnershipRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.GetCarOwnersRequest) error); ok {
+		r1 = rf(ctx, carID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCarStats provides a mock function with given fields: ctx, input
+func (_m *Repository) GetCarStats(ctx context.Context, input domain.GetCarStatsRequest) (models.CarStats, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCarStats")
+	}
+
+	var r0 models.CarStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarStatsRequest) (models.CarStats, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetCarStatsRequest) models.CarStats); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.CarStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.GetCarStatsRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCars provides a mock function with given fields: ctx, input
 func (_m *Repository) GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error) {
 	ret := _m.Called(ctx, input)
@@ -80,16 +338,138 @@ func (_m *Repository) GetCars(ctx context.Context, input domain.GetCarsRequest)
 	return r0, r1
 }
 
-// UpdateCar provides a mock function with given fields: ctx, carID, input
-func (_m *Repository) UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest) error {
+// ListenCarEvents provides a mock function with given fields: ctx, fn
+func (_m *Repository) ListenCarEvents(ctx context.Context, fn func() error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListenCarEvents")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func() error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PatchCar provides a mock function with given fields: ctx, carID, patch, version
+func (_m *Repository) PatchCar(ctx context.Context, carID int, patch domain.CarPatch, version *int) (int, error) {
+	ret := _m.Called(ctx, carID, patch, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchCar")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.CarPatch, *int) (int, error)); ok {
+		return rf(ctx, carID, patch, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.CarPatch, *int) int); ok {
+		r0 = rf(ctx, carID, patch, version)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.CarPatch, *int) error); ok {
+		r1 = rf(ctx, carID, patch, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeCars provides a mock function with given fields: ctx, deletedBefore
+func (_m *Repository) PurgeCars(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	ret := _m.Called(ctx, deletedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeCars")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]int, error)); ok {
+		return rf(ctx, deletedBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []int); ok {
+		r0 = rf(ctx, deletedBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, deletedBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreCar provides a mock function with given fields: ctx, carID
+func (_m *Repository) RestoreCar(ctx context.Context, carID int) error {
+	ret := _m.Called(ctx, carID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreCar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, carID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchCars provides a mock function with given fields: ctx, input
+func (_m *Repository) SearchCars(ctx context.Context, input domain.SearchCarsRequest) (models.CarList, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchCars")
+	}
+
+	var r0 models.CarList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchCarsRequest) (models.CarList, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchCarsRequest) models.CarList); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.CarList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.SearchCarsRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransferCar provides a mock function with given fields: ctx, carID, input
+func (_m *Repository) TransferCar(ctx context.Context, carID int, input domain.TransferCarRequest) error {
 	ret := _m.Called(ctx, carID, input)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateCar")
+		panic("no return value specified for TransferCar")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateCarsRequest) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.TransferCarRequest) error); ok {
 		r0 = rf(ctx, carID, input)
 	} else {
 		r0 = ret.Error(0)
@@ -4,20 +4,39 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
 	"github.com/Verce11o/effective-mobile-test/internal/models"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"strings"
+	"sync"
+	"time"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Repository
 type Repository interface {
-	CreateCars(ctx context.Context, cars []domain.Car) error
+	CreateCars(ctx context.Context, cars []domain.Car, onConflict string) (models.CreateCarsResult, error)
 	GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error)
-	UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest) error
-	DeleteCar(ctx context.Context, carID int) error
+	CountCars(ctx context.Context, input domain.GetCarsRequest, estimate bool) (int, error)
+	SearchCars(ctx context.Context, input domain.SearchCarsRequest) (models.CarList, error)
+	GetCarStats(ctx context.Context, input domain.GetCarStatsRequest) (models.CarStats, error)
+	ExportCars(ctx context.Context, input domain.GetCarsRequest, fn func(car models.Car) error) error
+	GetCar(ctx context.Context, carID int) (models.Car, error)
+	GetCarByRegNum(ctx context.Context, regNum string) (models.Car, error)
+	PatchCar(ctx context.Context, carID int, patch domain.CarPatch, version *int) (int, error)
+	DeleteCar(ctx context.Context, carID int, version *int) error
+	RestoreCar(ctx context.Context, carID int) error
+	PurgeCars(ctx context.Context, deletedBefore time.Time) ([]int, error)
+	TransferCar(ctx context.Context, carID int, input domain.TransferCarRequest) error
+	GetCarOwners(ctx context.Context, carID int, input domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error)
+	GetCarHistory(ctx context.Context, carID int, input domain.GetCarHistoryRequest) (models.AuditList, error)
+	GetCarEvents(ctx context.Context, after models.EventPosition, input domain.CarEventsRequest, limit int) ([]models.CarEvent, error)
+	CurrentCarEventPosition(ctx context.Context) (models.EventPosition, error)
+	ListenCarEvents(ctx context.Context, fn func() error) error
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=CacheRepository
@@ -25,11 +44,40 @@ type CacheRepository interface {
 	GetCarList(ctx context.Context, hash string) (*models.CarList, error)
 	SetByIDCtx(ctx context.Context, cursor string, cars models.CarList) error
 	DeleteCarList(ctx context.Context) error
+	GetCarCount(ctx context.Context, hash string) (*int, error)
+	SetCarCount(ctx context.Context, hash string, count int) error
+	GetCarStats(ctx context.Context, hash string) (*models.CarStats, error)
+	SetCarStats(ctx context.Context, hash string, stats models.CarStats) error
+	GetCar(ctx context.Context, carID int) (*models.Car, error)
+	GetCarByRegNum(ctx context.Context, regNum string) (*models.Car, error)
+	SetCar(ctx context.Context, car models.Car) error
+	DeleteCar(ctx context.Context, carID int) error
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=ApiCommunicator
 type ApiCommunicator interface {
-	GetCarInfo(regNum string) (domain.Car, error)
+	GetCarInfo(ctx context.Context, regNum string) (domain.Car, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=CarInfoCache
+type CarInfoCache interface {
+	Evict(ctx context.Context, regNum string) error
+}
+
+const (
+	// statsDefaultDays is the number of days cars added per day are counted for by default.
+	statsDefaultDays = 30
+	// statsMaxDays limits the window of cars added per day.
+	statsMaxDays       = 366
+	statsDefaultOwners = 10
+)
+
+// EnrichOptions limits how plates of one request are looked up in the external api.
+type EnrichOptions struct {
+	// Concurrency is the number of parallel lookups, values below 1 mean sequential lookups.
+	Concurrency int
+	// Timeout is the deadline for all lookups of one request, zero means no deadline.
+	Timeout time.Duration
 }
 
 type Service struct {
@@ -38,51 +86,67 @@ type Service struct {
 	cache        CacheRepository
 	tracer       trace.Tracer
 	communicator ApiCommunicator
+	carInfoCache CarInfoCache
+	enrich       EnrichOptions
+	// retention is how long deleted cars are kept before PurgeCars removes them.
+	retention time.Duration
+	events    *carEventHub
 }
 
-func NewService(log *zap.SugaredLogger, repo Repository, cache CacheRepository, tracer trace.Tracer, communicator ApiCommunicator) *Service {
-	return &Service{log: log, repo: repo, cache: cache, tracer: tracer, communicator: communicator}
+func NewService(log *zap.SugaredLogger, repo Repository, cache CacheRepository, tracer trace.Tracer, communicator ApiCommunicator,
+	carInfoCache CarInfoCache, enrich EnrichOptions, retention time.Duration) *Service {
+	return &Service{log: log, repo: repo, cache: cache, tracer: tracer, communicator: communicator,
+		carInfoCache: carInfoCache, enrich: enrich, retention: retention, events: newCarEventHub()}
 }
 
-func (s *Service) CreateCar(ctx context.Context, input domain.CreateCarsRequest) error {
+func (s *Service) CreateCar(ctx context.Context, input domain.CreateCarsRequest) (models.CreateCarsResult, error) {
 	ctx, span := s.tracer.Start(ctx, "carService.CreateCar")
 	defer span.End()
 
-	cars := make([]domain.Car, 0, len(input.RegNums))
+	regNums := uniqueRegNums(input.RegNums)
 
-	span.AddEvent("iterate over reg nums")
+	cars, failed, err := s.enrichCars(ctx, regNums, input.Partial)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 
-	for _, regNum := range input.RegNums {
+		s.log.Infof("err get car info: %v", err)
+		return models.CreateCarsResult{}, err
+	}
 
-		span.AddEvent("call external api")
+	// in partial mode existing plates are reported per plate instead of failing the whole batch
+	onConflict := input.OnConflict
+	if input.Partial && onConflict != domain.OnConflictReturn {
+		onConflict = domain.OnConflictSkip
+	}
 
-		car, err := s.communicator.GetCarInfo(regNum)
-		if err != nil {
-			return err
-		}
+	result := models.CreateCarsResult{
+		Created: make([]models.Car, 0),
+	}
 
-		cars = append(cars, car)
+	if len(cars) > 0 {
+		span.AddEvent("call postgres repo")
+		result, err = s.repo.CreateCars(ctx, cars, onConflict)
 
-	}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 
-	span.AddEvent("call postgres repo")
-	err := s.repo.CreateCars(ctx, cars)
+			s.log.Infof("err create cars: %v", err)
+			return models.CreateCarsResult{}, err
+		}
 
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
+		span.AddEvent("clear redis cache")
+		if err = s.cache.DeleteCarList(ctx); err != nil {
+			s.log.Infof("cannot clear cache: %v", err)
+		}
 
-		s.log.Infof("err create cars: %v", err)
-		return err
+		s.log.Debugf("cleared cars cache")
 	}
 
-	span.AddEvent("clear redis cache")
-	if err = s.cache.DeleteCarList(ctx); err != nil {
-		s.log.Infof("cannot clear cache: %v", err)
-	}
+	result.Results, result.CreatedIDs = plateResults(input.RegNums, result, failed)
 
-	s.log.Debugf("cleared cars cache")
-	return err
+	return result, nil
 }
 
 func (s *Service) GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error) {
@@ -123,6 +187,20 @@ func (s *Service) GetCars(ctx context.Context, input domain.GetCarsRequest) (mod
 		return carList, fmt.Errorf("get cars: %w", err)
 	}
 
+	if input.IncludeTotal {
+		total, err := s.countCars(ctx, input)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			s.log.Infof("cannot count cars: %v", err)
+			return models.CarList{}, fmt.Errorf("count cars: %w", err)
+		}
+
+		carList.Total = &total
+		carList.TotalEstimated = input.TotalMode == domain.TotalEstimate
+	}
+
 	if err = s.cache.SetByIDCtx(ctx, hashStr, carList); err != nil {
 		s.log.Infof("cannot set product list in redis: %v", err)
 	}
@@ -131,31 +209,296 @@ func (s *Service) GetCars(ctx context.Context, input domain.GetCarsRequest) (mod
 
 }
 
-func (s *Service) UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest) error {
+// countCars caches the count separately from pages, so that it is computed once for all pages of a query.
+func (s *Service) countCars(ctx context.Context, input domain.GetCarsRequest) (int, error) {
+	estimate := input.TotalMode == domain.TotalEstimate
+
+	filter := input
+	filter.Cursor, filter.Sort, filter.Limit = "", "", 0
+	filter.Fields, filter.Embed = "", ""
+
+	paramsBytes, err := json.Marshal(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	hashStr := fmt.Sprintf("%x", sha256.Sum256(paramsBytes))
+
+	cachedCount, err := s.cache.GetCarCount(ctx, hashStr)
+
+	if err != nil {
+		s.log.Debugf("cannot get car count in redis: %v", err)
+	}
+
+	if cachedCount != nil {
+		return *cachedCount, nil
+	}
+
+	count, err := s.repo.CountCars(ctx, input, estimate)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.cache.SetCarCount(ctx, hashStr, count); err != nil {
+		s.log.Infof("cannot set car count in redis: %v", err)
+	}
+
+	return count, nil
+}
+
+func (s *Service) SearchCars(ctx context.Context, input domain.SearchCarsRequest) (models.CarList, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.SearchCars")
+	defer span.End()
+
+	paramsBytes, err := json.Marshal(input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("err marshalling params: %v", err)
+		return models.CarList{}, err
+	}
+
+	// search results share the cache with car lists, so that writes invalidate both
+	hash := sha256.Sum256(append([]byte("search:"), paramsBytes...))
+	hashStr := fmt.Sprintf("%x", hash)
+
+	cachedCars, err := s.cache.GetCarList(ctx, hashStr)
+
+	if err != nil {
+		s.log.Debugf("cannot get search result in redis: %v", err)
+	}
+
+	if cachedCars != nil {
+		return *cachedCars, nil
+	}
+
+	carList, err := s.repo.SearchCars(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot search cars: %v", err)
+		return models.CarList{}, fmt.Errorf("search cars: %w", err)
+	}
+
+	if err = s.cache.SetByIDCtx(ctx, hashStr, carList); err != nil {
+		s.log.Infof("cannot set search result in redis: %v", err)
+	}
+
+	return carList, nil
+}
+
+// GetCarStats aggregates cars matching the filters of input. The window of cars added per day
+// ends today and lasts statsDefaultDays unless it is set.
+func (s *Service) GetCarStats(ctx context.Context, input domain.GetCarStatsRequest) (models.CarStats, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.GetCarStats")
+	defer span.End()
+
+	input.Cursor, input.Sort, input.Limit = "", "", 0
+	input.IncludeTotal, input.TotalMode = false, ""
+	input.Fields, input.Embed = "", ""
+
+	if input.To.IsZero() {
+		input.To = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+
+	if input.From.IsZero() {
+		input.From = input.To.AddDate(0, 0, -(statsDefaultDays - 1))
+	}
+
+	if input.TopOwners == 0 {
+		input.TopOwners = statsDefaultOwners
+	}
+
+	if input.From.After(input.To) || input.To.Sub(input.From) >= statsMaxDays*24*time.Hour {
+		err := fmt.Errorf("%w: stats window must be from 1 to %d days", response.ErrInvalidRequest, statsMaxDays)
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return models.CarStats{}, err
+	}
+
+	paramsBytes, err := json.Marshal(input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("err marshalling params: %v", err)
+		return models.CarStats{}, err
+	}
+
+	hashStr := fmt.Sprintf("%x", sha256.Sum256(paramsBytes))
+
+	cachedStats, err := s.cache.GetCarStats(ctx, hashStr)
+
+	if err != nil {
+		s.log.Debugf("cannot get car stats in redis: %v", err)
+	}
+
+	if cachedStats != nil {
+		return *cachedStats, nil
+	}
+
+	stats, err := s.repo.GetCarStats(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get car stats: %v", err)
+		return models.CarStats{}, fmt.Errorf("get car stats: %w", err)
+	}
+
+	if err = s.cache.SetCarStats(ctx, hashStr, stats); err != nil {
+		s.log.Infof("cannot set car stats in redis: %v", err)
+	}
+
+	return stats, nil
+}
+
+// ExportCars passes every car matching the filters of input to fn. Exports are not cached.
+func (s *Service) ExportCars(ctx context.Context, input domain.GetCarsRequest, fn func(car models.Car) error) error {
+	ctx, span := s.tracer.Start(ctx, "carService.ExportCars")
+	defer span.End()
+
+	if err := s.repo.ExportCars(ctx, input, fn); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot export cars: %v", err)
+		return fmt.Errorf("export cars: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetCar(ctx context.Context, carID int) (models.Car, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.GetCar")
+	defer span.End()
+
+	cachedCar, err := s.cache.GetCar(ctx, carID)
+
+	if err != nil {
+		s.log.Debugf("cannot get car in redis: %v", err)
+	}
+
+	if cachedCar != nil {
+		s.log.Debugf("get cached car")
+		return *cachedCar, nil
+	}
+
+	car, err := s.repo.GetCar(ctx, carID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get car: %v", err)
+		return models.Car{}, fmt.Errorf("get car: %w", err)
+	}
+
+	if err = s.cache.SetCar(ctx, car); err != nil {
+		s.log.Infof("cannot set car in redis: %v", err)
+	}
+
+	return car, nil
+}
+
+func (s *Service) GetCarByRegNum(ctx context.Context, regNum string) (models.Car, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.GetCarByRegNum")
+	defer span.End()
+
+	cachedCar, err := s.cache.GetCarByRegNum(ctx, regNum)
+
+	if err != nil {
+		s.log.Debugf("cannot get car in redis: %v", err)
+	}
+
+	if cachedCar != nil {
+		s.log.Debugf("get cached car")
+		return *cachedCar, nil
+	}
+
+	car, err := s.repo.GetCarByRegNum(ctx, regNum)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get car by reg num: %v", err)
+		return models.Car{}, fmt.Errorf("get car by reg num: %w", err)
+	}
+
+	if err = s.cache.SetCar(ctx, car); err != nil {
+		s.log.Infof("cannot set car in redis: %v", err)
+	}
+
+	return car, nil
+}
+
+// UpdateCar replaces all fields of the car and returns its new version. With version set
+// the car is only updated if it still has that version.
+func (s *Service) UpdateCar(ctx context.Context, carID int, input domain.UpdateCarsRequest, version *int) (int, error) {
 	ctx, span := s.tracer.Start(ctx, "carService.UpdateCar")
 	defer span.End()
 
-	err := s.repo.UpdateCar(ctx, carID, input)
+	updated, err := s.patchCar(ctx, carID, input.Patch(), version)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 
 		s.log.Infof("cannot update car: %v", err)
-		return fmt.Errorf("update car: %w", err)
+		return 0, fmt.Errorf("update car: %w", err)
+	}
+
+	return updated, nil
+}
+
+// PatchCar applies a merge patch to the car, fields missing in the patch are left as they are.
+// It returns the new version of the car, with version set the car must still have that version.
+func (s *Service) PatchCar(ctx context.Context, carID int, patch domain.CarPatch, version *int) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.PatchCar")
+	defer span.End()
+
+	updated, err := s.patchCar(ctx, carID, patch, version)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot patch car: %v", err)
+		return 0, fmt.Errorf("patch car: %w", err)
+	}
+
+	return updated, nil
+}
+
+// patchCar validates fields of patch, the rest of the car is valid already, so the result is valid too.
+func (s *Service) patchCar(ctx context.Context, carID int, patch domain.CarPatch, version *int) (int, error) {
+	if err := validateCarPatch(patch); err != nil {
+		return 0, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	updated, err := s.repo.PatchCar(ctx, carID, patch, version)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.cache.DeleteCar(ctx, carID); err != nil {
+		s.log.Infof("cannot delete car %v from cache: %v", carID, err)
 	}
 
 	if err = s.cache.DeleteCarList(ctx); err != nil {
 		s.log.Infof("cannot clear cache: %v", err)
 	}
 
-	return nil
+	return updated, nil
 }
 
-func (s *Service) DeleteCar(ctx context.Context, carID int) error {
+// DeleteCar marks the car as deleted, with version set the car must still have that version.
+func (s *Service) DeleteCar(ctx context.Context, carID int, version *int) error {
 	ctx, span := s.tracer.Start(ctx, "carService.DeleteCar")
 	defer span.End()
 
-	err := s.repo.DeleteCar(ctx, carID)
+	err := s.repo.DeleteCar(ctx, carID, version)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -164,9 +507,352 @@ func (s *Service) DeleteCar(ctx context.Context, carID int) error {
 		return fmt.Errorf("delete car: %w", err)
 	}
 
+	if err = s.cache.DeleteCar(ctx, carID); err != nil {
+		s.log.Infof("cannot delete car %v from cache: %v", carID, err)
+	}
+
+	if err = s.cache.DeleteCarList(ctx); err != nil {
+		s.log.Infof("cannot clear cache: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) RestoreCar(ctx context.Context, carID int) error {
+	ctx, span := s.tracer.Start(ctx, "carService.RestoreCar")
+	defer span.End()
+
+	err := s.repo.RestoreCar(ctx, carID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot restore car: %v", err)
+		return fmt.Errorf("restore car: %w", err)
+	}
+
 	if err = s.cache.DeleteCarList(ctx); err != nil {
 		s.log.Infof("cannot clear cache: %v", err)
 	}
 
 	return nil
 }
+
+// PurgeCars permanently removes cars deleted longer than retention ago and returns their number.
+func (s *Service) PurgeCars(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.PurgeCars")
+	defer span.End()
+
+	purged, err := s.repo.PurgeCars(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot purge cars: %v", err)
+		return 0, fmt.Errorf("purge cars: %w", err)
+	}
+
+	if len(purged) == 0 {
+		return 0, nil
+	}
+
+	s.log.Infof("purged %v deleted cars", len(purged))
+
+	if err = s.cache.DeleteCarList(ctx); err != nil {
+		s.log.Infof("cannot clear cache: %v", err)
+	}
+
+	return len(purged), nil
+}
+
+func (s *Service) EvictCarInfo(ctx context.Context, regNum string) error {
+	ctx, span := s.tracer.Start(ctx, "carService.EvictCarInfo")
+	defer span.End()
+
+	err := s.carInfoCache.Evict(ctx, regNum)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot evict car info %v: %v", regNum, err)
+		return fmt.Errorf("evict car info: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) TransferCar(ctx context.Context, carID int, input domain.TransferCarRequest) error {
+	ctx, span := s.tracer.Start(ctx, "carService.TransferCar")
+	defer span.End()
+
+	err := s.repo.TransferCar(ctx, carID, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot transfer car: %v", err)
+		return fmt.Errorf("transfer car: %w", err)
+	}
+
+	if err = s.cache.DeleteCar(ctx, carID); err != nil {
+		s.log.Infof("cannot delete car %v from cache: %v", carID, err)
+	}
+
+	if err = s.cache.DeleteCarList(ctx); err != nil {
+		s.log.Infof("cannot clear cache: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetCarOwners(ctx context.Context, carID int, input domain.GetCarOwnersRequest) ([]models.OwnershipRecord, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.GetCarOwners")
+	defer span.End()
+
+	records, err := s.repo.GetCarOwners(ctx, carID, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get car owners: %v", err)
+		return nil, fmt.Errorf("get car owners: %w", err)
+	}
+
+	return records, nil
+}
+
+func (s *Service) GetCarHistory(ctx context.Context, carID int, input domain.GetCarHistoryRequest) (models.AuditList, error) {
+	ctx, span := s.tracer.Start(ctx, "carService.GetCarHistory")
+	defer span.End()
+
+	history, err := s.repo.GetCarHistory(ctx, carID, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get car history: %v", err)
+		return models.AuditList{}, fmt.Errorf("get car history: %w", err)
+	}
+
+	return history, nil
+}
+
+// validateCarPatch rejects clearing required fields and years no car can have.
+func validateCarPatch(patch domain.CarPatch) error {
+	required := []struct {
+		name  string
+		field domain.Optional[string]
+	}{
+		{"regNum", patch.RegNum},
+		{"mark", patch.Mark},
+		{"model", patch.Model},
+	}
+
+	for _, r := range required {
+		if r.field.Set && (r.field.Value == nil || strings.TrimSpace(*r.field.Value) == "") {
+			return fmt.Errorf("%s cannot be empty", r.name)
+		}
+	}
+
+	if patch.Year.Set && patch.Year.Value != nil && !validYear(*patch.Year.Value) {
+		return fmt.Errorf("year %d is out of range", *patch.Year.Value)
+	}
+
+	return nil
+}
+
+// validYear reports whether year can be a year a car was made, next year models are on sale already.
+func validYear(year int) bool {
+	return year >= 1886 && year <= time.Now().Year()+1
+}
+
+// uniqueRegNums drops repeated plates keeping the order of the first occurrence.
+func uniqueRegNums(regNums []string) []string {
+	seen := make(map[string]struct{}, len(regNums))
+	unique := make([]string, 0, len(regNums))
+
+	for _, regNum := range regNums {
+		if _, ok := seen[regNum]; ok {
+			continue
+		}
+
+		seen[regNum] = struct{}{}
+		unique = append(unique, regNum)
+	}
+
+	return unique
+}
+
+// enrichCars looks plates up in the external api using a bounded pool of workers.
+// Without partial the first failed lookup cancels the rest and its error is returned,
+// otherwise failed plates are reported in the map, including the ones that did not
+// make it before the deadline. Cancellation of ctx always aborts enrichment.
+func (s *Service) enrichCars(ctx context.Context, regNums []string, partial bool) ([]domain.Car, map[string]models.PlateResult, error) {
+	parentCtx := ctx
+
+	ctx, span := s.tracer.Start(ctx, "carService.enrichCars")
+	defer span.End()
+
+	if s.enrich.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, s.enrich.Timeout)
+		defer cancelTimeout()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := min(max(s.enrich.Concurrency, 1), len(regNums))
+
+	type lookup struct {
+		car domain.Car
+		err error
+	}
+
+	lookups := make([]lookup, len(regNums))
+	jobs := make(chan int)
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+
+	span.AddEvent("call external api")
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				car, err := s.communicator.GetCarInfo(ctx, regNums[idx])
+				lookups[idx] = lookup{car: car, err: err}
+
+				if err != nil && !partial {
+					failOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	dispatched := 0
+
+feed:
+	for dispatched < len(regNums) {
+		select {
+		case jobs <- dispatched:
+			dispatched++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if err := parentCtx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	for idx := dispatched; idx < len(regNums); idx++ {
+		lookups[idx].err = fmt.Errorf("%w: %w", response.ErrGettingCarInfo, ctx.Err())
+	}
+
+	cars := make([]domain.Car, 0, len(regNums))
+	failed := make(map[string]models.PlateResult)
+
+	for idx, l := range lookups {
+		if l.err == nil {
+			cars = append(cars, l.car)
+			continue
+		}
+
+		if !partial {
+			return nil, nil, l.err
+		}
+
+		// the error can tell about the external api, clients only get the reason of the status
+		s.log.Infof("cannot get car info of %v: %v", regNums[idx], l.err)
+
+		status := plateStatus(l.err)
+
+		failed[regNums[idx]] = models.PlateResult{
+			RegNum: regNums[idx],
+			Status: status,
+			Error:  plateReasons[status],
+		}
+	}
+
+	return cars, failed, nil
+}
+
+// plateReasons are errors reported for plates that could not be looked up.
+var plateReasons = map[string]string{
+	models.PlateStatusNotFound:      "car not found",
+	models.PlateStatusInvalid:       "invalid plate",
+	models.PlateStatusUpstreamError: "car registry unavailable",
+}
+
+func plateStatus(err error) string {
+	switch {
+	case errors.Is(err, response.ErrInvalidRegNum):
+		return models.PlateStatusInvalid
+	case errors.Is(err, response.ErrCarInfoNotFound):
+		return models.PlateStatusNotFound
+	default:
+		return models.PlateStatusUpstreamError
+	}
+}
+
+// plateResults builds the status of every requested plate in request order.
+func plateResults(regNums []string, result models.CreateCarsResult, failed map[string]models.PlateResult) ([]models.PlateResult, []int) {
+	created := make(map[string]int, len(result.Created))
+	createdIDs := make([]int, 0, len(result.Created))
+	for _, car := range result.Created {
+		created[car.RegNum] = car.ID
+		createdIDs = append(createdIDs, car.ID)
+	}
+
+	existing := make(map[string]int, len(result.Existing)+len(result.Skipped))
+	for _, regNum := range result.Skipped {
+		existing[regNum] = 0
+	}
+	for _, car := range result.Existing {
+		existing[car.RegNum] = car.ID
+	}
+
+	results := make([]models.PlateResult, 0, len(regNums))
+	seen := make(map[string]struct{}, len(regNums))
+
+	for _, regNum := range regNums {
+		if _, ok := seen[regNum]; ok {
+			results = append(results, models.PlateResult{RegNum: regNum, Status: models.PlateStatusDuplicate, Error: "repeated in request"})
+			continue
+		}
+		seen[regNum] = struct{}{}
+
+		if plate, ok := failed[regNum]; ok {
+			results = append(results, plate)
+			continue
+		}
+
+		if carID, ok := created[regNum]; ok {
+			results = append(results, models.PlateResult{RegNum: regNum, Status: models.PlateStatusCreated, CarID: carID})
+			continue
+		}
+
+		if carID, ok := existing[regNum]; ok {
+			results = append(results, models.PlateResult{RegNum: regNum, Status: models.PlateStatusDuplicate, CarID: carID, Error: "car already exists"})
+		}
+	}
+
+	return results, createdIDs
+}
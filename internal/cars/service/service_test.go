@@ -2,15 +2,20 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	repoMock "github.com/Verce11o/effective-mobile-test/internal/cars/service/mocks"
 	"github.com/Verce11o/effective-mobile-test/internal/domain"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
 	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestService_CreateCar(t *testing.T) {
@@ -54,10 +59,10 @@ func TestService_CreateCar(t *testing.T) {
 			cache := repoMock.NewCacheRepository(t)
 			communicator := repoMock.NewApiCommunicator(t)
 
-			repo.On("CreateCars", mock.Anything, mock.AnythingOfType("[]domain.Car")).Maybe().Return(nil)
+			repo.On("CreateCars", mock.Anything, mock.AnythingOfType("[]domain.Car"), tt.args.input.OnConflict).Maybe().Return(models.CreateCarsResult{}, nil)
 			cache.On("DeleteCarList", mock.Anything).Maybe().Return(nil)
 
-			communicator.On("GetCarInfo", mock.AnythingOfType("string")).Return(domain.Car{}, nil)
+			communicator.On("GetCarInfo", mock.Anything, mock.AnythingOfType("string")).Return(domain.Car{}, nil)
 
 			s := &Service{
 				log:          log,
@@ -67,7 +72,7 @@ func TestService_CreateCar(t *testing.T) {
 				communicator: communicator,
 			}
 
-			err := s.CreateCar(tt.args.ctx, tt.args.input)
+			_, err := s.CreateCar(tt.args.ctx, tt.args.input)
 
 			if err != nil {
 				require.EqualError(t, err, tt.wantErr.Error())
@@ -76,6 +81,152 @@ func TestService_CreateCar(t *testing.T) {
 	}
 }
 
+func TestService_CreateCarConflict(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+	communicator := repoMock.NewApiCommunicator(t)
+
+	communicator.On("GetCarInfo", mock.Anything, "J623FP555").Return(domain.Car{RegNum: "J623FP555"}, nil).Once()
+	repo.On("CreateCars", mock.Anything, []domain.Car{{RegNum: "J623FP555"}}, domain.OnConflictFail).
+		Return(models.CreateCarsResult{}, &response.ConflictError{RegNums: []string{"J623FP555"}}).Once()
+
+	s := &Service{
+		log:          logger.NewMockLogger(),
+		repo:         repo,
+		cache:        cache,
+		tracer:       tracer.InitTracer(ctx, "", ""),
+		communicator: communicator,
+	}
+
+	_, err := s.CreateCar(ctx, domain.CreateCarsRequest{
+		RegNums:    []string{"J623FP555", "J623FP555"},
+		OnConflict: domain.OnConflictFail,
+	})
+
+	var conflictErr *response.ConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, []string{"J623FP555"}, conflictErr.RegNums)
+}
+
+func TestService_CreateCarPartial(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+	communicator := repoMock.NewApiCommunicator(t)
+
+	communicator.On("GetCarInfo", mock.Anything, "J623FP555").Return(domain.Car{RegNum: "J623FP555"}, nil).Once()
+	communicator.On("GetCarInfo", mock.Anything, "Z407GI541").Return(domain.Car{RegNum: "Z407GI541"}, nil).Once()
+	communicator.On("GetCarInfo", mock.Anything, "A000AA000").Return(domain.Car{}, response.ErrCarInfoNotFound).Once()
+	communicator.On("GetCarInfo", mock.Anything, "bad").Return(domain.Car{}, response.ErrInvalidRegNum).Once()
+
+	repo.On("CreateCars", mock.Anything, []domain.Car{{RegNum: "J623FP555"}, {RegNum: "Z407GI541"}}, domain.OnConflictSkip).
+		Return(models.CreateCarsResult{
+			Created: []models.Car{{ID: 7, RegNum: "J623FP555"}},
+			Skipped: []string{"Z407GI541"},
+		}, nil).Once()
+	cache.On("DeleteCarList", mock.Anything).Return(nil).Once()
+
+	s := &Service{
+		log:          logger.NewMockLogger(),
+		repo:         repo,
+		cache:        cache,
+		tracer:       tracer.InitTracer(ctx, "", ""),
+		communicator: communicator,
+		enrich:       EnrichOptions{Concurrency: 3},
+	}
+
+	result, err := s.CreateCar(ctx, domain.CreateCarsRequest{
+		RegNums: []string{"J623FP555", "A000AA000", "bad", "Z407GI541", "J623FP555"},
+		Partial: true,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []int{7}, result.CreatedIDs)
+
+	statuses := make([]string, 0, len(result.Results))
+	for _, plate := range result.Results {
+		statuses = append(statuses, plate.Status)
+	}
+
+	require.Equal(t, []string{
+		models.PlateStatusCreated,
+		models.PlateStatusNotFound,
+		models.PlateStatusInvalid,
+		models.PlateStatusDuplicate,
+		models.PlateStatusDuplicate,
+	}, statuses)
+	require.Equal(t, "car not found", result.Results[1].Error)
+	require.Equal(t, "invalid plate", result.Results[2].Error)
+}
+
+func TestService_CreateCarEnrichDeadline(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+	communicator := repoMock.NewApiCommunicator(t)
+
+	communicator.On("GetCarInfo", mock.Anything, "J623FP555").Return(domain.Car{RegNum: "J623FP555"}, nil).Once()
+	communicator.On("GetCarInfo", mock.Anything, "Z407GI541").Return(func(ctx context.Context, regNum string) (domain.Car, error) {
+		<-ctx.Done()
+		return domain.Car{}, fmt.Errorf("%w: %w", response.ErrGettingCarInfo, ctx.Err())
+	}).Once()
+
+	repo.On("CreateCars", mock.Anything, []domain.Car{{RegNum: "J623FP555"}}, domain.OnConflictSkip).
+		Return(models.CreateCarsResult{Created: []models.Car{{ID: 1, RegNum: "J623FP555"}}}, nil).Once()
+	cache.On("DeleteCarList", mock.Anything).Return(nil).Once()
+
+	s := &Service{
+		log:          logger.NewMockLogger(),
+		repo:         repo,
+		cache:        cache,
+		tracer:       tracer.InitTracer(ctx, "", ""),
+		communicator: communicator,
+		enrich:       EnrichOptions{Concurrency: 2, Timeout: 50 * time.Millisecond},
+	}
+
+	result, err := s.CreateCar(ctx, domain.CreateCarsRequest{
+		RegNums: []string{"J623FP555", "Z407GI541"},
+		Partial: true,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, models.PlateStatusCreated, result.Results[0].Status)
+	require.Equal(t, models.PlateStatusUpstreamError, result.Results[1].Status)
+	require.Equal(t, "car registry unavailable", result.Results[1].Error)
+}
+
+func TestService_CreateCarCancelsLookups(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+	communicator := repoMock.NewApiCommunicator(t)
+
+	communicator.On("GetCarInfo", mock.Anything, "A000AA000").Return(domain.Car{}, response.ErrCarInfoNotFound).Once()
+	communicator.On("GetCarInfo", mock.Anything, "J623FP555").Return(func(ctx context.Context, regNum string) (domain.Car, error) {
+		<-ctx.Done()
+		return domain.Car{}, ctx.Err()
+	}).Maybe()
+
+	s := &Service{
+		log:          logger.NewMockLogger(),
+		repo:         repo,
+		cache:        cache,
+		tracer:       tracer.InitTracer(ctx, "", ""),
+		communicator: communicator,
+		enrich:       EnrichOptions{Concurrency: 2},
+	}
+
+	_, err := s.CreateCar(ctx, domain.CreateCarsRequest{
+		RegNums: []string{"J623FP555", "A000AA000"},
+	})
+	require.ErrorIs(t, err, response.ErrCarInfoNotFound)
+}
+
 func TestService_GetCars(t *testing.T) {
 
 	type args struct {
@@ -131,7 +282,276 @@ func TestService_GetCars(t *testing.T) {
 	}
 }
 
+func TestService_GetCarsTotal(t *testing.T) {
+	cachedCount := 42
+
+	tests := []struct {
+		name          string
+		input         domain.GetCarsRequest
+		cachedCount   *int
+		wantTotal     int
+		wantEstimated bool
+	}{
+		{
+			name:      "exact count",
+			input:     domain.GetCarsRequest{Mark: "BMW", IncludeTotal: true},
+			wantTotal: 7,
+		},
+		{
+			name:          "estimated count",
+			input:         domain.GetCarsRequest{Mark: "BMW", IncludeTotal: true, TotalMode: domain.TotalEstimate},
+			wantTotal:     7,
+			wantEstimated: true,
+		},
+		{
+			name:        "cached count",
+			input:       domain.GetCarsRequest{Mark: "BMW", IncludeTotal: true},
+			cachedCount: &cachedCount,
+			wantTotal:   42,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+
+			cache.On("GetCarList", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Once()
+			repo.On("GetCars", mock.Anything, tt.input).Return(models.CarList{}, nil).Once()
+			cache.On("GetCarCount", mock.Anything, mock.AnythingOfType("string")).Return(tt.cachedCount, nil).Once()
+
+			if tt.cachedCount == nil {
+				repo.On("CountCars", mock.Anything, tt.input, tt.wantEstimated).Return(7, nil).Once()
+				cache.On("SetCarCount", mock.Anything, mock.AnythingOfType("string"), 7).Return(nil).Once()
+			}
+
+			cache.On("SetByIDCtx", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(func(cars models.CarList) bool {
+				return cars.Total != nil && *cars.Total == tt.wantTotal
+			})).Return(nil).Once()
+
+			s := &Service{
+				log:    log,
+				repo:   repo,
+				cache:  cache,
+				tracer: tracer.InitTracer(ctx, "", ""),
+			}
+
+			cars, err := s.GetCars(ctx, tt.input)
+			require.NoError(t, err)
+			require.NotNil(t, cars.Total)
+			require.Equal(t, tt.wantTotal, *cars.Total)
+			require.Equal(t, tt.wantEstimated, cars.TotalEstimated)
+		})
+	}
+}
+
+func TestService_GetCarsCacheKeyIncludesOwnerFilters(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+
+	keys := make(map[string]struct{})
+
+	cache.On("GetCarList", mock.Anything, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		keys[args.String(1)] = struct{}{}
+	}).Return(nil, nil)
+	repo.On("GetCars", mock.Anything, mock.AnythingOfType("domain.GetCarsRequest")).Return(models.CarList{}, nil)
+	cache.On("SetByIDCtx", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("models.CarList")).Return(nil)
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		cache:  cache,
+		tracer: tracer.InitTracer(ctx, "", ""),
+	}
+
+	inputs := []domain.GetCarsRequest{
+		{OwnerSurname: "Ivanov"},
+		{OwnerSurname: "Ivanov", OwnerMatch: domain.MatchPrefix},
+		{OwnerSurname: "Ivanov", OwnerIgnoreCase: true},
+		{OwnerSurname: "Ivanov", OwnerName: "Ivan"},
+	}
+
+	for _, input := range inputs {
+		_, err := s.GetCars(ctx, input)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, keys, len(inputs))
+}
+
+func TestService_GetCarsCacheKeyIncludesProjection(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+
+	keys := make(map[string]struct{})
+
+	cache.On("GetCarList", mock.Anything, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		keys[args.String(1)] = struct{}{}
+	}).Return(nil, nil)
+	repo.On("GetCars", mock.Anything, mock.AnythingOfType("domain.GetCarsRequest")).Return(models.CarList{}, nil)
+	cache.On("SetByIDCtx", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("models.CarList")).Return(nil)
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		cache:  cache,
+		tracer: tracer.InitTracer(ctx, "", ""),
+	}
+
+	inputs := []domain.GetCarsRequest{
+		{Mark: "BMW"},
+		{Mark: "BMW", Fields: "id,regNum"},
+		{Mark: "BMW", Fields: "id,regNum", Embed: domain.EmbedOwner},
+		{Mark: "BMW", Fields: "id"},
+	}
+
+	for _, input := range inputs {
+		_, err := s.GetCars(ctx, input)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, keys, len(inputs))
+}
+
+func TestService_SearchCars(t *testing.T) {
+	tests := []struct {
+		name   string
+		cached *models.CarList
+	}{
+		{
+			name: "cache miss",
+		},
+		{
+			name:   "cache hit",
+			cached: &models.CarList{Cars: []models.Car{{ID: 1}}},
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			input := domain.SearchCarsRequest{Q: "merc"}
+
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+
+			cache.On("GetCarList", mock.Anything, mock.AnythingOfType("string")).Return(tt.cached, nil).Once()
+
+			if tt.cached == nil {
+				repo.On("SearchCars", mock.Anything, input).Return(models.CarList{Cars: []models.Car{{ID: 1}}}, nil).Once()
+				cache.On("SetByIDCtx", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("models.CarList")).Return(nil).Once()
+			}
+
+			s := &Service{
+				log:    log,
+				repo:   repo,
+				cache:  cache,
+				tracer: tracer.InitTracer(ctx, "", ""),
+			}
+
+			cars, err := s.SearchCars(ctx, input)
+			require.NoError(t, err)
+			require.Len(t, cars.Cars, 1)
+		})
+	}
+}
+
+func TestService_GetCarStats(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   domain.GetCarStatsRequest
+		cached  *models.CarStats
+		want    func(input domain.GetCarStatsRequest) bool
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			input: domain.GetCarStatsRequest{
+				GetCarsRequest: domain.GetCarsRequest{Mark: "BMW", Cursor: "cursor", Limit: 5},
+			},
+			want: func(input domain.GetCarStatsRequest) bool {
+				return input.Mark == "BMW" && input.Cursor == "" && input.Limit == 0 && input.TopOwners == 10 &&
+					input.To.Sub(input.From) == 29*24*time.Hour
+			},
+		},
+		{
+			name:  "explicit window",
+			input: domain.GetCarStatsRequest{From: from, To: to, TopOwners: 3},
+			want: func(input domain.GetCarStatsRequest) bool {
+				return input.From.Equal(from) && input.To.Equal(to) && input.TopOwners == 3
+			},
+		},
+		{
+			name:   "cache hit",
+			input:  domain.GetCarStatsRequest{From: from, To: to},
+			cached: &models.CarStats{Total: 5},
+		},
+		{
+			name:    "from after to",
+			input:   domain.GetCarStatsRequest{From: to, To: from},
+			wantErr: response.ErrInvalidRequest,
+		},
+		{
+			name:    "window too long",
+			input:   domain.GetCarStatsRequest{From: from.AddDate(-1, 0, -1), To: to},
+			wantErr: response.ErrInvalidRequest,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+
+			if tt.wantErr == nil {
+				cache.On("GetCarStats", mock.Anything, mock.AnythingOfType("string")).Return(tt.cached, nil).Once()
+			}
+
+			if tt.want != nil {
+				repo.On("GetCarStats", mock.Anything, mock.MatchedBy(tt.want)).Return(models.CarStats{Total: 5}, nil).Once()
+				cache.On("SetCarStats", mock.Anything, mock.AnythingOfType("string"), models.CarStats{Total: 5}).Return(nil).Once()
+			}
+
+			s := &Service{
+				log:    log,
+				repo:   repo,
+				cache:  cache,
+				tracer: tracer.InitTracer(ctx, "", ""),
+			}
+
+			stats, err := s.GetCarStats(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, 5, stats.Total)
+		})
+	}
+}
+
 func TestService_UpdateCar(t *testing.T) {
+	year := 2020
+	oldYear := 1800
+
 	type args struct {
 		ctx   context.Context
 		carID int
@@ -147,11 +567,41 @@ func TestService_UpdateCar(t *testing.T) {
 			args: args{
 				ctx:   context.Background(),
 				carID: 1,
-				input: domain.UpdateCarsRequest{},
+				input: domain.UpdateCarsRequest{RegNum: "X123XX150", Mark: "Lada", Model: "Vesta", Year: &year},
+			},
+
+			wantErr: nil,
+		},
+		{
+			name: "missing year is cleared",
+			args: args{
+				ctx:   context.Background(),
+				carID: 1,
+				input: domain.UpdateCarsRequest{RegNum: "X123XX150", Mark: "Lada", Model: "Vesta"},
 			},
 
 			wantErr: nil,
 		},
+		{
+			name: "empty mark",
+			args: args{
+				ctx:   context.Background(),
+				carID: 1,
+				input: domain.UpdateCarsRequest{RegNum: "X123XX150", Model: "Vesta"},
+			},
+
+			wantErr: response.ErrInvalidRequest,
+		},
+		{
+			name: "year out of range",
+			args: args{
+				ctx:   context.Background(),
+				carID: 1,
+				input: domain.UpdateCarsRequest{RegNum: "X123XX150", Mark: "Lada", Model: "Vesta", Year: &oldYear},
+			},
+
+			wantErr: response.ErrInvalidRequest,
+		},
 	}
 
 	log := logger.NewMockLogger()
@@ -162,8 +612,11 @@ func TestService_UpdateCar(t *testing.T) {
 			cache := repoMock.NewCacheRepository(t)
 			communicator := repoMock.NewApiCommunicator(t)
 
-			repo.On("UpdateCar", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("domain.UpdateCarsRequest")).Return(nil).Once()
-			cache.On("DeleteCarList", mock.Anything).Maybe().Return(nil)
+			if tt.wantErr == nil {
+				repo.On("PatchCar", mock.Anything, tt.args.carID, tt.args.input.Patch(), (*int)(nil)).Return(2, nil).Once()
+				cache.On("DeleteCarList", mock.Anything).Maybe().Return(nil)
+				cache.On("DeleteCar", mock.Anything, tt.args.carID).Return(nil).Once()
+			}
 
 			s := &Service{
 				log:          log,
@@ -172,12 +625,94 @@ func TestService_UpdateCar(t *testing.T) {
 				tracer:       tracer.InitTracer(tt.args.ctx, "", ""),
 				communicator: communicator,
 			}
-			err := s.UpdateCar(tt.args.ctx, tt.args.carID, tt.args.input)
+			version, err := s.UpdateCar(tt.args.ctx, tt.args.carID, tt.args.input, nil)
 
-			if err != nil {
-				require.EqualError(t, err, tt.wantErr.Error())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
 			}
 
+			require.NoError(t, err)
+			require.Equal(t, 2, version)
+		})
+	}
+}
+
+func TestService_PatchCar(t *testing.T) {
+	staleVersion := 3
+
+	tests := []struct {
+		name    string
+		patch   string
+		version *int
+		wantErr error
+	}{
+		{
+			name:  "clear year",
+			patch: `{"year": null}`,
+		},
+		{
+			name:  "set some fields",
+			patch: `{"mark": "BMW", "year": 2018}`,
+		},
+		{
+			name:    "null mark",
+			patch:   `{"mark": null}`,
+			wantErr: response.ErrInvalidRequest,
+		},
+		{
+			name:    "empty reg num",
+			patch:   `{"regNum": " "}`,
+			wantErr: response.ErrInvalidRequest,
+		},
+		{
+			name:    "repository error",
+			patch:   `{"model": "X5"}`,
+			wantErr: pgx.ErrNoRows,
+		},
+		{
+			name:    "stale version",
+			patch:   `{"model": "X5"}`,
+			version: &staleVersion,
+			wantErr: response.ErrPreconditionFailed,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var patch domain.CarPatch
+			require.NoError(t, json.Unmarshal([]byte(tt.patch), &patch))
+
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+
+			if !errors.Is(tt.wantErr, response.ErrInvalidRequest) {
+				repo.On("PatchCar", mock.Anything, 1, patch, tt.version).Return(4, tt.wantErr).Once()
+			}
+
+			if tt.wantErr == nil {
+				cache.On("DeleteCar", mock.Anything, 1).Return(nil).Once()
+				cache.On("DeleteCarList", mock.Anything).Return(nil).Once()
+			}
+
+			s := &Service{
+				log:    log,
+				repo:   repo,
+				cache:  cache,
+				tracer: tracer.InitTracer(ctx, "", ""),
+			}
+
+			_, err := s.PatchCar(ctx, 1, patch, tt.version)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
 		})
 	}
 }
@@ -208,8 +743,9 @@ func TestService_DeleteCar(t *testing.T) {
 		cache := repoMock.NewCacheRepository(t)
 		communicator := repoMock.NewApiCommunicator(t)
 
-		repo.On("DeleteCar", mock.Anything, mock.AnythingOfType("int")).Return(nil).Once()
+		repo.On("DeleteCar", mock.Anything, mock.AnythingOfType("int"), (*int)(nil)).Return(nil).Once()
 		cache.On("DeleteCarList", mock.Anything).Maybe().Return(nil)
+		cache.On("DeleteCar", mock.Anything, tt.args.carID).Return(nil).Once()
 
 		t.Run(tt.name, func(t *testing.T) {
 			s := &Service{
@@ -220,10 +756,221 @@ func TestService_DeleteCar(t *testing.T) {
 				communicator: communicator,
 			}
 
-			err := s.DeleteCar(tt.args.ctx, tt.args.carID)
+			err := s.DeleteCar(tt.args.ctx, tt.args.carID, nil)
 			if err != nil {
 				require.EqualError(t, err, tt.wantErr.Error())
 			}
 		})
 	}
 }
+
+func TestService_RestoreCar(t *testing.T) {
+	tests := []struct {
+		name    string
+		carID   int
+		wantErr error
+	}{
+		{
+			name:  "success",
+			carID: 1,
+		},
+		{
+			name:    "plate taken by another car",
+			carID:   2,
+			wantErr: &response.ConflictError{RegNums: []string{"X123XX150"}},
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+
+			repo.On("RestoreCar", mock.Anything, tt.carID).Return(tt.wantErr).Once()
+			cache.On("DeleteCarList", mock.Anything).Maybe().Return(nil)
+
+			s := &Service{
+				log:    log,
+				repo:   repo,
+				cache:  cache,
+				tracer: tracer.InitTracer(ctx, "", ""),
+			}
+
+			err := s.RestoreCar(ctx, tt.carID)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_PurgeCars(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cache := repoMock.NewCacheRepository(t)
+
+	retention := 24 * time.Hour
+
+	repo.On("PurgeCars", mock.Anything, mock.MatchedBy(func(deletedBefore time.Time) bool {
+		return time.Since(deletedBefore) >= retention
+	})).Return([]int{1, 2}, nil).Once()
+	cache.On("DeleteCarList", mock.Anything).Return(nil).Once()
+
+	s := &Service{
+		log:       logger.NewMockLogger(),
+		repo:      repo,
+		cache:     cache,
+		tracer:    tracer.InitTracer(ctx, "", ""),
+		retention: retention,
+	}
+
+	purged, err := s.PurgeCars(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, purged)
+}
+
+func TestService_TransferCar(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		carID int
+		input domain.TransferCarRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:   context.Background(),
+				carID: 1,
+				input: domain.TransferCarRequest{OwnerID: 2},
+			},
+		},
+		{
+			name: "owner not found",
+			args: args{
+				ctx:   context.Background(),
+				carID: 1,
+				input: domain.TransferCarRequest{OwnerID: 3},
+			},
+			wantErr: response.ErrNotFound,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+			communicator := repoMock.NewApiCommunicator(t)
+
+			repo.On("TransferCar", mock.Anything, tt.args.carID, tt.args.input).Return(tt.wantErr).Once()
+			cache.On("DeleteCarList", mock.Anything).Maybe().Return(nil)
+			cache.On("DeleteCar", mock.Anything, tt.args.carID).Maybe().Return(nil)
+
+			s := &Service{
+				log:          log,
+				repo:         repo,
+				cache:        cache,
+				tracer:       tracer.InitTracer(tt.args.ctx, "", ""),
+				communicator: communicator,
+			}
+
+			err := s.TransferCar(tt.args.ctx, tt.args.carID, tt.args.input)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_GetCar(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		carID int
+	}
+	tests := []struct {
+		name      string
+		args      args
+		cachedCar *models.Car
+		wantErr   error
+	}{
+		{
+			name: "from cache",
+			args: args{
+				ctx:   context.Background(),
+				carID: 1,
+			},
+			cachedCar: &models.Car{ID: 1, RegNum: "X123XX150"},
+		},
+		{
+			name: "from repository",
+			args: args{
+				ctx:   context.Background(),
+				carID: 1,
+			},
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:   context.Background(),
+				carID: 2,
+			},
+			wantErr: pgx.ErrNoRows,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repoMock.NewRepository(t)
+			cache := repoMock.NewCacheRepository(t)
+			communicator := repoMock.NewApiCommunicator(t)
+
+			cache.On("GetCar", mock.Anything, tt.args.carID).Return(tt.cachedCar, nil).Once()
+
+			if tt.cachedCar == nil {
+				repo.On("GetCar", mock.Anything, tt.args.carID).Return(models.Car{ID: tt.args.carID}, tt.wantErr).Once()
+			}
+
+			if tt.cachedCar == nil && tt.wantErr == nil {
+				cache.On("SetCar", mock.Anything, models.Car{ID: tt.args.carID}).Return(nil).Once()
+			}
+
+			s := &Service{
+				log:          log,
+				repo:         repo,
+				cache:        cache,
+				tracer:       tracer.InitTracer(tt.args.ctx, "", ""),
+				communicator: communicator,
+			}
+
+			car, err := s.GetCar(tt.args.ctx, tt.args.carID)
+			require.ErrorIs(t, err, tt.wantErr)
+
+			if tt.wantErr == nil {
+				require.Equal(t, tt.args.carID, car.ID)
+			}
+		})
+	}
+}
+
+func TestService_EvictCarInfo(t *testing.T) {
+	ctx := context.Background()
+
+	carInfoCache := repoMock.NewCarInfoCache(t)
+	carInfoCache.On("Evict", mock.Anything, "X123XX150").Return(nil).Once()
+
+	s := &Service{
+		log:          logger.NewMockLogger(),
+		tracer:       tracer.InitTracer(ctx, "", ""),
+		carInfoCache: carInfoCache,
+	}
+
+	require.NoError(t, s.EvictCarInfo(ctx, "X123XX150"))
+}
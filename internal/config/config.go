@@ -3,12 +3,18 @@ package config
 import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
+	"time"
 )
 
 type Config struct {
 	Env             string `env:"env"`
 	Server          Server
+	HTTPCache       HTTPCache
+	Cars            Cars
 	ExternalCarsApi ExternalCarsApi
+	Jobs            Jobs
+	Webhooks        Webhooks
+	Pagination      Pagination
 	Postgres        Postgres
 	Redis           Redis
 	Jaeger          Jaeger
@@ -23,8 +29,79 @@ type Server struct {
 	Port string `env:"SERVER_PORT" env-default:"3000"`
 }
 
+// HTTPCache holds Cache-Control headers of reads per route, empty values send none.
+// Cached responses are revalidated with ETag and Last-Modified.
+type HTTPCache struct {
+	CarList string `env:"HTTP_CACHE_CONTROL_CAR_LIST" env-default:"private, no-cache"`
+	Car     string `env:"HTTP_CACHE_CONTROL_CAR" env-default:"private, no-cache"`
+}
+
+type Cars struct {
+	// DeletedRetention is how long deleted cars can be restored before purge removes them.
+	DeletedRetention time.Duration `env:"CARS_DELETED_RETENTION" env-default:"720h"`
+}
+
 type ExternalCarsApi struct {
-	URL string `env:"EXTERNAL_CARS_API_URL" env-default:"http://localhost:3009"`
+	URL     string        `env:"EXTERNAL_CARS_API_URL" env-default:"http://localhost:3009"`
+	Timeout time.Duration `env:"EXTERNAL_CARS_API_TIMEOUT" env-default:"3s"`
+	// Concurrency limits how many plates of one request are looked up at the same time.
+	Concurrency int `env:"EXTERNAL_CARS_API_CONCURRENCY" env-default:"10"`
+	// EnrichTimeout is the overall deadline for looking up all plates of one request,
+	// and for one lookup shared by several requests.
+	EnrichTimeout time.Duration `env:"EXTERNAL_CARS_API_ENRICH_TIMEOUT" env-default:"30s"`
+	Resilience    ExternalCarsApiResilience
+	Cache         ExternalCarsApiCache
+}
+
+type ExternalCarsApiCache struct {
+	// TTL of found cars, zero disables caching of them.
+	TTL time.Duration `env:"EXTERNAL_CARS_API_CACHE_TTL" env-default:"24h"`
+	// NotFoundTTL of plates unknown to the external api, zero disables negative caching.
+	NotFoundTTL time.Duration `env:"EXTERNAL_CARS_API_CACHE_NOT_FOUND_TTL" env-default:"10m"`
+}
+
+type ExternalCarsApiResilience struct {
+	// MaxRetries is the number of retries of one lookup after a transient error.
+	MaxRetries     int           `env:"EXTERNAL_CARS_API_MAX_RETRIES" env-default:"3"`
+	RetryBaseDelay time.Duration `env:"EXTERNAL_CARS_API_RETRY_BASE_DELAY" env-default:"100ms"`
+	RetryMaxDelay  time.Duration `env:"EXTERNAL_CARS_API_RETRY_MAX_DELAY" env-default:"2s"`
+	// BreakerThreshold is the number of consecutive failures that opens the circuit breaker.
+	BreakerThreshold   int           `env:"EXTERNAL_CARS_API_BREAKER_THRESHOLD" env-default:"5"`
+	BreakerOpenTimeout time.Duration `env:"EXTERNAL_CARS_API_BREAKER_OPEN_TIMEOUT" env-default:"30s"`
+	// RateLimit is the number of requests per second, zero disables the limiter.
+	RateLimit float64 `env:"EXTERNAL_CARS_API_RATE_LIMIT" env-default:"50"`
+	RateBurst int     `env:"EXTERNAL_CARS_API_RATE_BURST" env-default:"10"`
+}
+
+type Jobs struct {
+	Workers      int           `env:"JOBS_WORKERS" env-default:"2"`
+	PollInterval time.Duration `env:"JOBS_POLL_INTERVAL" env-default:"1s"`
+	// ChunkSize is the number of plates of a job created at once, progress is saved after every chunk.
+	ChunkSize int `env:"JOBS_CHUNK_SIZE" env-default:"50"`
+	// Lease must exceed the time needed for one chunk, otherwise the job is picked up by another worker.
+	Lease       time.Duration `env:"JOBS_LEASE" env-default:"2m"`
+	MaxAttempts int           `env:"JOBS_MAX_ATTEMPTS" env-default:"3"`
+}
+
+type Webhooks struct {
+	Workers int `env:"WEBHOOKS_WORKERS" env-default:"2"`
+	// PollInterval is the pause of idle delivery workers. Car changes are fanned out to webhooks
+	// when notified, a change whose notification is missed waits for up to PollInterval.
+	PollInterval time.Duration `env:"WEBHOOKS_POLL_INTERVAL" env-default:"1s"`
+	// Timeout limits one delivery attempt, Lease must exceed it.
+	Timeout time.Duration `env:"WEBHOOKS_TIMEOUT" env-default:"10s"`
+	Lease   time.Duration `env:"WEBHOOKS_LEASE" env-default:"1m"`
+	// MaxAttempts is the number of attempts before a delivery is dead-lettered.
+	MaxAttempts    int           `env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"8"`
+	RetryBaseDelay time.Duration `env:"WEBHOOKS_RETRY_BASE_DELAY" env-default:"10s"`
+	RetryMaxDelay  time.Duration `env:"WEBHOOKS_RETRY_MAX_DELAY" env-default:"1h"`
+}
+
+type Pagination struct {
+	// CursorSecret signs cursors, with an empty secret a random one is generated on start.
+	CursorSecret string `env:"PAGINATION_CURSOR_SECRET"`
+	DefaultLimit int    `env:"PAGINATION_DEFAULT_LIMIT" env-default:"10"`
+	MaxLimit     int    `env:"PAGINATION_MAX_LIMIT" env-default:"100"`
 }
 
 type Postgres struct {
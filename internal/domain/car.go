@@ -1,7 +1,43 @@
 package domain
 
+import "time"
+
+const (
+	OnConflictFail   = "fail"
+	OnConflictSkip   = "skip"
+	OnConflictReturn = "return"
+)
+
+const (
+	MatchExact  = "exact"
+	MatchPrefix = "prefix"
+)
+
+const EmbedOwner = "owner"
+
+const (
+	ImportReportJSON = "json"
+	ImportReportCSV  = "csv"
+)
+
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+)
+
+const (
+	TotalExact    = "exact"
+	TotalEstimate = "estimate"
+)
+
 type CreateCarsRequest struct {
 	RegNums []string `json:"regNums" binding:"required,gt=0"`
+	// OnConflict defines what happens with plates that already exist: fail (default), skip or return.
+	OnConflict string `json:"onConflict" binding:"omitempty,oneof=fail skip return"`
+	// Partial creates every plate that can be created and reports the rest instead of failing the request.
+	Partial bool `json:"partial"`
+	// Async enqueues a job instead of creating cars during the request, plates are then processed as partial.
+	Async bool `json:"async"`
 }
 
 type GetCarsRequest struct {
@@ -11,13 +47,70 @@ type GetCarsRequest struct {
 	Model   string `form:"model"`
 	Year    int    `form:"year"`
 	OwnerID int    `form:"owner_id"`
+	// OwnerName, OwnerSurname and OwnerPatronymic are compared according to OwnerMatch and OwnerIgnoreCase.
+	OwnerName       string `form:"owner_name"`
+	OwnerSurname    string `form:"owner_surname"`
+	OwnerPatronymic string `form:"owner_patronymic"`
+	// OwnerMatch is exact (default) or prefix.
+	OwnerMatch      string `form:"owner_match" binding:"omitempty,oneof=exact prefix"`
+	OwnerIgnoreCase bool   `form:"owner_ignore_case"`
+	Limit           int    `form:"limit" binding:"omitempty,gt=0"`
+	// Sort is a comma separated list of fields, "-" before a field sorts it in descending order.
+	Sort string `form:"sort"`
+	// IncludeDeleted adds soft deleted cars to the result.
+	IncludeDeleted bool `form:"include_deleted"`
+	// IncludeTotal counts all cars matching the filters, TotalMode is exact (default) or estimate.
+	IncludeTotal bool   `form:"include_total"`
+	TotalMode    string `form:"total_mode" binding:"omitempty,oneof=exact estimate"`
+	// Fields is a comma separated list of car fields to return, all of them by default.
+	// With Fields set the owner is only returned if Embed is owner.
+	Fields string `form:"fields"`
+	Embed  string `form:"embed" binding:"omitempty,oneof=owner"`
+}
+
+// ImportCarsRequest chooses how rejected rows of an import are reported, json (default) or a csv file.
+type ImportCarsRequest struct {
+	Report string `form:"report" binding:"omitempty,oneof=json csv"`
+}
+
+// ExportCarsRequest accepts the filters and sort of GetCarsRequest, the whole result is exported.
+type ExportCarsRequest struct {
+	GetCarsRequest
+	Format string `form:"format" binding:"required,oneof=csv ndjson"`
+}
+
+// GetCarStatsRequest accepts the same filters as GetCarsRequest, its paging and total params are ignored.
+type GetCarStatsRequest struct {
+	GetCarsRequest
+	// From and To are days bounding cars added per day, both included. The last 30 days by default.
+	From time.Time `form:"from" time_format:"2006-01-02"`
+	To   time.Time `form:"to" time_format:"2006-01-02"`
+	// TopOwners is the number of owners with most cars, 10 by default.
+	TopOwners int `form:"top_owners" binding:"omitempty,gt=0,lte=100"`
+}
+
+type SearchCarsRequest struct {
+	Q      string `form:"q" binding:"required"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,gt=0"`
 }
 
+// UpdateCarsRequest replaces all fields of a car, a missing year clears it.
 type UpdateCarsRequest struct {
-	RegNum string `json:"regNum"`
-	Mark   string `json:"mark"`
-	Model  string `json:"model"`
-	Year   int    `json:"year"`
+	RegNum string `json:"regNum" binding:"required"`
+	Mark   string `json:"mark" binding:"required"`
+	Model  string `json:"model" binding:"required"`
+	Year   *int   `json:"year"`
+}
+
+// Patch returns a merge patch that sets every field of the car.
+func (r UpdateCarsRequest) Patch() CarPatch {
+	return CarPatch{
+		RegNum: Optional[string]{Set: true, Value: &r.RegNum},
+		Mark:   Optional[string]{Set: true, Value: &r.Mark},
+		Model:  Optional[string]{Set: true, Value: &r.Model},
+		Year:   Optional[int]{Set: true, Value: r.Year},
+	}
 }
 
 type Car struct {
@@ -33,3 +126,25 @@ type People struct {
 	Patronymic *string `json:"patronymic,omitempty"`
 	Surname    string  `json:"surname"`
 }
+
+type TransferCarRequest struct {
+	OwnerID     int        `json:"owner_id" binding:"required,gt=0"`
+	EffectiveAt *time.Time `json:"effective_at"`
+}
+
+type GetCarHistoryRequest struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,gt=0"`
+}
+
+// CarEventsRequest filters the change feed. Events of cars that left the filter are sent too.
+type CarEventsRequest struct {
+	Mark    string `form:"mark"`
+	OwnerID int    `form:"owner_id" binding:"omitempty,gt=0"`
+	// LastEventID resumes the feed after this event, the Last-Event-ID header takes precedence.
+	LastEventID string `form:"last_event_id"`
+}
+
+type GetCarOwnersRequest struct {
+	At time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
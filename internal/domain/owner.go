@@ -0,0 +1,20 @@
+package domain
+
+type CreateOwnerRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	Surname    string  `json:"surname" binding:"required"`
+	Patronymic *string `json:"patronymic"`
+}
+
+type GetOwnersRequest struct {
+	Cursor  string `form:"cursor"`
+	Limit   int    `form:"limit" binding:"omitempty,gt=0"`
+	Name    string `form:"name"`
+	Surname string `form:"surname"`
+}
+
+type UpdateOwnerRequest struct {
+	Name       string  `json:"name"`
+	Surname    string  `json:"surname"`
+	Patronymic *string `json:"patronymic"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Optional is a field of a merge patch. Set is false when the key is missing,
+// Value is nil when the key is null, which clears the field.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	o.Value = &value
+
+	return nil
+}
+
+// CarPatch is an RFC 7396 merge patch of a car.
+type CarPatch struct {
+	RegNum Optional[string] `json:"regNum" swaggertype:"string"`
+	Mark   Optional[string] `json:"mark" swaggertype:"string"`
+	Model  Optional[string] `json:"model" swaggertype:"string"`
+	Year   Optional[int]    `json:"year" swaggertype:"integer"`
+}
+
+// UnmarshalJSON rejects documents that are not objects and keys a car has no field for.
+func (p *CarPatch) UnmarshalJSON(data []byte) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil || keys == nil {
+		return fmt.Errorf("merge patch must be an object")
+	}
+
+	for key := range keys {
+		switch key {
+		case "regNum", "mark", "model", "year":
+		default:
+			return fmt.Errorf("field %q cannot be patched", key)
+		}
+	}
+
+	type carPatch CarPatch
+
+	return json.Unmarshal(data, (*carPatch)(p))
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCarPatchUnmarshalJSON(t *testing.T) {
+	mark := "BMW"
+
+	tests := []struct {
+		name    string
+		data    string
+		want    CarPatch
+		wantErr bool
+	}{
+		{
+			name: "missing keys are not set",
+			data: `{"mark": "BMW"}`,
+			want: CarPatch{Mark: Optional[string]{Set: true, Value: &mark}},
+		},
+		{
+			name: "null clears",
+			data: `{"year": null}`,
+			want: CarPatch{Year: Optional[int]{Set: true}},
+		},
+		{
+			name: "empty patch",
+			data: `{}`,
+		},
+		{
+			name:    "unknown key",
+			data:    `{"owner": null}`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			data:    `null`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong type",
+			data:    `{"year": "2018"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch CarPatch
+
+			err := json.Unmarshal([]byte(tt.data), &patch)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, patch)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+// CreateWebhookRequest subscribes url to events. A secret is generated when none is given.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=car.created car.updated car.deleted"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	Active *bool    `json:"active"`
+}
+
+// UpdateWebhookRequest replaces url, events and active state of the webhook, the secret
+// is only rotated when given.
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=car.created car.updated car.deleted"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	Active *bool    `json:"active"`
+}
+
+type GetWebhooksRequest struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,gt=0"`
+}
+
+type GetWebhookDeliveriesRequest struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,gt=0"`
+	Status string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+}
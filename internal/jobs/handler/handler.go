@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Service
+type Service interface {
+	GetJob(ctx context.Context, jobID int) (models.Job, error)
+}
+
+type Handler struct {
+	log     *zap.SugaredLogger
+	service Service
+	tracer  trace.Tracer
+}
+
+func NewHandler(log *zap.SugaredLogger, service Service, tracer trace.Tracer) *Handler {
+	return &Handler{log: log, service: service, tracer: tracer}
+}
+
+// GetJob godoc
+// @Summary Get job
+// @Description Get progress of an asynchronous car creation job and outcomes of already processed plates
+// @Tags jobs
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Job ID"
+// @Success 200 {object} models.Job
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /jobs/{id} [get]
+func (h *Handler) GetJob(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "jobHandler.GetJob")
+	defer span.End()
+
+	jobID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "id must be integer",
+		})
+		return
+	}
+
+	job, err := h.service.GetJob(ctx, jobID)
+	if err != nil {
+		h.log.Infof("error while getting job %v: %v", jobID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
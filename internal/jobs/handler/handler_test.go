@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"github.com/Verce11o/effective-mobile-test/internal/jobs/handler/mocks"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_GetJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		statusCode int
+		wantErr    error
+	}{
+		{
+			name:       "get job",
+			id:         "1",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "invalid id",
+			id:         "abc",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "job not found",
+			id:         "2",
+			statusCode: http.StatusNotFound,
+			wantErr:    pgx.ErrNoRows,
+		},
+	}
+
+	log := logger.NewMockLogger()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+
+		ctx.Request = &http.Request{
+			Method: http.MethodGet,
+			Header: make(http.Header),
+		}
+		ctx.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+		serviceMock := mocks.NewService(t)
+
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				log:     log,
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			serviceMock.On("GetJob", mock.Anything, mock.AnythingOfType("int")).Return(models.Job{}, tt.wantErr).Maybe()
+			h.GetJob(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+type JobRepository struct {
+	db     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewJobRepository(db *pgxpool.Pool, tracer trace.Tracer) *JobRepository {
+	return &JobRepository{db: db, tracer: tracer}
+}
+
+func (j *JobRepository) CreateJob(ctx context.Context, input domain.CreateCarsRequest) (models.Job, error) {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.CreateJob")
+	defer span.End()
+
+	q := `INSERT INTO car_jobs (request, total) VALUES ($1, $2)
+			RETURNING id, status, total, processed, created_at`
+
+	job := models.Job{}
+
+	err := j.db.QueryRow(ctx, q, input, len(input.RegNums)).
+		Scan(&job.ID, &job.Status, &job.Total, &job.Processed, &job.CreatedAt)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	return job, nil
+}
+
+// ClaimJob locks the oldest queued job, or a running one whose lease has expired,
+// for lease duration. pgx.ErrNoRows is returned when there is nothing to do.
+func (j *JobRepository) ClaimJob(ctx context.Context, lease time.Duration) (models.Job, domain.CreateCarsRequest, error) {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.ClaimJob")
+	defer span.End()
+
+	q := `UPDATE car_jobs
+			SET status = 'running',
+				attempts = attempts + 1,
+				started_at = COALESCE(started_at, NOW() AT TIME ZONE 'utc'),
+				locked_until = NOW() AT TIME ZONE 'utc' + make_interval(secs => $1)
+			WHERE id = (SELECT id
+						FROM car_jobs
+						WHERE status = 'queued'
+						   OR (status = 'running' AND locked_until < NOW() AT TIME ZONE 'utc')
+						ORDER BY id
+						LIMIT 1 FOR UPDATE SKIP LOCKED)
+			RETURNING id, status, total, processed, attempts, result, request, created_at, started_at,
+				COALESCE(chunk_end, 0)`
+
+	var job models.Job
+	var input domain.CreateCarsRequest
+
+	err := j.db.QueryRow(ctx, q, lease.Seconds()).Scan(&job.ID, &job.Status, &job.Total, &job.Processed,
+		&job.Attempts, &job.Result, &input, &job.CreatedAt, &job.StartedAt, &job.ChunkEnd)
+	if err != nil {
+		return models.Job{}, domain.CreateCarsRequest{}, err
+	}
+
+	return job, input, nil
+}
+
+// Writes of a worker are fenced by the attempt it claimed the job with, they return pgx.ErrNoRows
+// once the lease expired and another worker claimed the job.
+
+// StartChunk records the chunk of plates up to end the worker is about to create and extends the
+// lease of the job.
+func (j *JobRepository) StartChunk(ctx context.Context, jobID int, attempt int, end int, lease time.Duration) error {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.StartChunk")
+	defer span.End()
+
+	q := `UPDATE car_jobs
+			SET chunk_end = $1,
+				locked_until = NOW() AT TIME ZONE 'utc' + make_interval(secs => $2)
+			WHERE id = $3 AND attempts = $4 AND status = 'running'`
+
+	return j.exec(ctx, q, end, lease.Seconds(), jobID, attempt)
+}
+
+// SaveProgress stores outcomes of processed plates, ends the chunk and extends the lease of the job.
+func (j *JobRepository) SaveProgress(ctx context.Context, jobID int, attempt int, processed int,
+	result models.CreateCarsResult, lease time.Duration) error {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.SaveProgress")
+	defer span.End()
+
+	q := `UPDATE car_jobs SET processed = $1, result = $2, chunk_end = NULL,
+				locked_until = NOW() AT TIME ZONE 'utc' + make_interval(secs => $3)
+			WHERE id = $4 AND attempts = $5 AND status = 'running'`
+
+	return j.exec(ctx, q, processed, result, lease.Seconds(), jobID, attempt)
+}
+
+func (j *JobRepository) FinishJob(ctx context.Context, jobID int, attempt int, status string, errMsg string) error {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.FinishJob")
+	defer span.End()
+
+	q := `UPDATE car_jobs SET status = $1, error = NULLIF($2, ''), locked_until = NULL,
+				finished_at = NOW() AT TIME ZONE 'utc'
+			WHERE id = $3 AND attempts = $4 AND status = 'running'`
+
+	return j.exec(ctx, q, status, errMsg, jobID, attempt)
+}
+
+// ReleaseJob puts an interrupted job back to the queue without counting the attempt.
+func (j *JobRepository) ReleaseJob(ctx context.Context, jobID int, attempt int) error {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.ReleaseJob")
+	defer span.End()
+
+	q := `UPDATE car_jobs SET status = 'queued', attempts = attempts - 1, locked_until = NULL
+			WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	return j.exec(ctx, q, jobID, attempt)
+}
+
+func (j *JobRepository) exec(ctx context.Context, q string, args ...any) error {
+	tag, err := j.db.Exec(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// CarsCreatedBy returns those of carIDs whose creation is recorded in the car audit with actor.
+func (j *JobRepository) CarsCreatedBy(ctx context.Context, actor string, carIDs []int) ([]int, error) {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.CarsCreatedBy")
+	defer span.End()
+
+	q := `SELECT car_id FROM car_audit WHERE car_id = ANY($1) AND operation = $2 AND actor = $3`
+
+	rows, err := j.db.Query(ctx, q, carIDs, models.AuditCreate, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+func (j *JobRepository) GetJob(ctx context.Context, jobID int) (models.Job, error) {
+	ctx, span := j.tracer.Start(ctx, "jobRepository.GetJob")
+	defer span.End()
+
+	q := `SELECT id, status, total, processed, attempts, result, COALESCE(error, ''),
+				created_at, started_at, finished_at
+			FROM car_jobs WHERE id = $1`
+
+	var job models.Job
+
+	err := j.db.QueryRow(ctx, q, jobID).Scan(&job.ID, &job.Status, &job.Total, &job.Processed, &job.Attempts,
+		&job.Result, &job.Error, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	return job, nil
+}
@@ -0,0 +1,59 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/Verce11o/effective-mobile-test/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/Verce11o/effective-mobile-test/internal/models"
+)
+
+// CarService is an autogenerated mock type for the CarService type
+type CarService struct {
+	mock.Mock
+}
+
+// CreateCar provides a mock function with given fields: ctx, input
+func (_m *CarService) CreateCar(ctx context.Context, input domain.CreateCarsRequest) (models.CreateCarsResult, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCar")
+	}
+
+	var r0 models.CreateCarsResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCarsRequest) (models.CreateCarsResult, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCarsRequest) models.CreateCarsResult); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.CreateCarsResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateCarsRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewCarService creates a new instance of CarService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCarService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CarService {
+	mock := &CarService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
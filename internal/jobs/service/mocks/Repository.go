@@ -0,0 +1,226 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/Verce11o/effective-mobile-test/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/Verce11o/effective-mobile-test/internal/models"
+
+	time "time"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// CarsCreatedBy provides a mock function with given fields: ctx, actor, carIDs
+func (_m *Repository) CarsCreatedBy(ctx context.Context, actor string, carIDs []int) ([]int, error) {
+	ret := _m.Called(ctx, actor, carIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CarsCreatedBy")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int) ([]int, error)); ok {
+		return rf(ctx, actor, carIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int) []int); ok {
+		r0 = rf(ctx, actor, carIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []int) error); ok {
+		r1 = rf(ctx, actor, carIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimJob provides a mock function with given fields: ctx, lease
+func (_m *Repository) ClaimJob(ctx context.Context, lease time.Duration) (models.Job, domain.CreateCarsRequest, error) {
+	ret := _m.Called(ctx, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimJob")
+	}
+
+	var r0 models.Job
+	var r1 domain.CreateCarsRequest
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) (models.Job, domain.CreateCarsRequest, error)); ok {
+		return rf(ctx, lease)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) models.Job); ok {
+		r0 = rf(ctx, lease)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) domain.CreateCarsRequest); ok {
+		r1 = rf(ctx, lease)
+	} else {
+		r1 = ret.Get(1).(domain.CreateCarsRequest)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, time.Duration) error); ok {
+		r2 = rf(ctx, lease)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CreateJob provides a mock function with given fields: ctx, input
+func (_m *Repository) CreateJob(ctx context.Context, input domain.CreateCarsRequest) (models.Job, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCarsRequest) (models.Job, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCarsRequest) models.Job); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateCarsRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FinishJob provides a mock function with given fields: ctx, jobID, attempt, status, errMsg
+func (_m *Repository) FinishJob(ctx context.Context, jobID int, attempt int, status string, errMsg string) error {
+	ret := _m.Called(ctx, jobID, attempt, status, errMsg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string, string) error); ok {
+		r0 = rf(ctx, jobID, attempt, status, errMsg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetJob provides a mock function with given fields: ctx, jobID
+func (_m *Repository) GetJob(ctx context.Context, jobID int) (models.Job, error) {
+	ret := _m.Called(ctx, jobID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Job, error)); ok {
+		return rf(ctx, jobID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Job); ok {
+		r0 = rf(ctx, jobID)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, jobID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseJob provides a mock function with given fields: ctx, jobID, attempt
+func (_m *Repository) ReleaseJob(ctx context.Context, jobID int, attempt int) error {
+	ret := _m.Called(ctx, jobID, attempt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, jobID, attempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveProgress provides a mock function with given fields: ctx, jobID, attempt, processed, result, lease
+func (_m *Repository) SaveProgress(ctx context.Context, jobID int, attempt int, processed int, result models.CreateCarsResult, lease time.Duration) error {
+	ret := _m.Called(ctx, jobID, attempt, processed, result, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveProgress")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, models.CreateCarsResult, time.Duration) error); ok {
+		r0 = rf(ctx, jobID, attempt, processed, result, lease)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartChunk provides a mock function with given fields: ctx, jobID, attempt, end, lease
+func (_m *Repository) StartChunk(ctx context.Context, jobID int, attempt int, end int, lease time.Duration) error {
+	ret := _m.Called(ctx, jobID, attempt, end, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartChunk")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, time.Duration) error); ok {
+		r0 = rf(ctx, jobID, attempt, end, lease)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
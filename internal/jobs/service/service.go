@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/actor"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Repository
+type Repository interface {
+	CreateJob(ctx context.Context, input domain.CreateCarsRequest) (models.Job, error)
+	ClaimJob(ctx context.Context, lease time.Duration) (models.Job, domain.CreateCarsRequest, error)
+	StartChunk(ctx context.Context, jobID int, attempt int, end int, lease time.Duration) error
+	SaveProgress(ctx context.Context, jobID int, attempt int, processed int, result models.CreateCarsResult, lease time.Duration) error
+	FinishJob(ctx context.Context, jobID int, attempt int, status string, errMsg string) error
+	ReleaseJob(ctx context.Context, jobID int, attempt int) error
+	GetJob(ctx context.Context, jobID int) (models.Job, error)
+	CarsCreatedBy(ctx context.Context, actor string, carIDs []int) ([]int, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=CarService
+type CarService interface {
+	CreateCar(ctx context.Context, input domain.CreateCarsRequest) (models.CreateCarsResult, error)
+}
+
+// errLeaseLost stops a worker whose lease expired, the worker that claimed the job since carries on.
+var errLeaseLost = errors.New("job was claimed by another worker")
+
+// Options configures the worker pool.
+type Options struct {
+	// Workers is the number of jobs processed at the same time.
+	Workers int
+	// PollInterval is the pause of an idle worker before it looks for a job again.
+	PollInterval time.Duration
+	// ChunkSize is the number of plates created at once, progress is saved after every chunk.
+	ChunkSize int
+	// Lease is how long a job stays locked by a worker without progress
+	// before other workers consider it abandoned.
+	Lease time.Duration
+	// MaxAttempts limits how many times an abandoned job is picked up again.
+	MaxAttempts int
+}
+
+type Service struct {
+	log    *zap.SugaredLogger
+	repo   Repository
+	cars   CarService
+	tracer trace.Tracer
+	opts   Options
+}
+
+func NewService(log *zap.SugaredLogger, repo Repository, cars CarService, tracer trace.Tracer, opts Options) *Service {
+	return &Service{log: log, repo: repo, cars: cars, tracer: tracer, opts: opts}
+}
+
+func (s *Service) EnqueueCreateCars(ctx context.Context, input domain.CreateCarsRequest) (models.Job, error) {
+	ctx, span := s.tracer.Start(ctx, "jobService.EnqueueCreateCars")
+	defer span.End()
+
+	input.Async = false
+
+	job, err := s.repo.CreateJob(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot create job: %v", err)
+		return models.Job{}, fmt.Errorf("create job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (s *Service) GetJob(ctx context.Context, jobID int) (models.Job, error) {
+	ctx, span := s.tracer.Start(ctx, "jobService.GetJob")
+	defer span.End()
+
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get job: %v", err)
+		return models.Job{}, fmt.Errorf("get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Run processes queued jobs until ctx is cancelled. Interrupted jobs are put back to the queue.
+func (s *Service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < max(s.opts.Workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Service) work(ctx context.Context) {
+	for {
+		found, err := s.processNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Infof("cannot process job: %v", err)
+		}
+
+		if found && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// processNext claims one job and processes it, found is false when the queue is empty.
+func (s *Service) processNext(ctx context.Context) (found bool, err error) {
+	job, input, err := s.repo.ClaimJob(ctx, s.opts.Lease)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("claim job: %w", err)
+	}
+
+	return true, s.process(ctx, job, input)
+}
+
+func (s *Service) process(ctx context.Context, job models.Job, input domain.CreateCarsRequest) error {
+	ctx, span := s.tracer.Start(ctx, "jobService.process")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("job.id", job.ID), attribute.Int("job.attempt", job.Attempts))
+
+	if s.opts.MaxAttempts > 0 && job.Attempts > s.opts.MaxAttempts {
+		return s.finish(ctx, job, models.JobStatusFailed, "too many attempts")
+	}
+
+	result := models.CreateCarsResult{
+		Created: make([]models.Car, 0),
+	}
+	if job.Result != nil {
+		result = *job.Result
+	}
+
+	chunkSize := max(s.opts.ChunkSize, 1)
+
+	// cars are audited as created by the job, which tells them apart after an interruption
+	ctx = actor.WithActor(ctx, jobActor(job.ID))
+
+	for offset, end := job.Processed, 0; offset < len(input.RegNums); offset = end {
+		end = min(offset+chunkSize, len(input.RegNums))
+		onConflict := input.OnConflict
+
+		// the previous attempt was interrupted in this chunk, existing cars are returned to find the ones it created
+		interrupted := offset == job.Processed && job.ChunkEnd > offset
+		if interrupted {
+			end = min(job.ChunkEnd, len(input.RegNums))
+			onConflict = domain.OnConflictReturn
+		}
+
+		if err := s.repo.StartChunk(ctx, job.ID, job.Attempts, end, s.opts.Lease); err != nil {
+			return fmt.Errorf("start job %v chunk: %w", job.ID, fenced(err))
+		}
+
+		// every plate gets its own outcome, so one bad plate does not fail the whole job
+		chunk, err := s.cars.CreateCar(ctx, domain.CreateCarsRequest{
+			RegNums:    input.RegNums[offset:end],
+			OnConflict: onConflict,
+			Partial:    true,
+		})
+
+		if err != nil && ctx.Err() != nil {
+			s.release(job)
+			return ctx.Err()
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			s.log.Infof("job %v failed: %v", job.ID, err)
+			return s.finish(ctx, job, models.JobStatusFailed, err.Error())
+		}
+
+		if interrupted {
+			carIDs := make([]int, 0, len(chunk.Existing))
+			for _, car := range chunk.Existing {
+				carIDs = append(carIDs, car.ID)
+			}
+
+			created, err := s.repo.CarsCreatedBy(ctx, jobActor(job.ID), carIDs)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+
+				return fmt.Errorf("get cars created by job %v: %w", job.ID, err)
+			}
+
+			recoverCreated(&chunk, created, input.OnConflict)
+		}
+
+		mergeResults(&result, chunk)
+
+		if err = s.repo.SaveProgress(ctx, job.ID, job.Attempts, end, result, s.opts.Lease); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			return fmt.Errorf("save job %v progress: %w", job.ID, fenced(err))
+		}
+	}
+
+	return s.finish(ctx, job, models.JobStatusCompleted, "")
+}
+
+func (s *Service) finish(ctx context.Context, job models.Job, status string, errMsg string) error {
+	if err := s.repo.FinishJob(ctx, job.ID, job.Attempts, status, errMsg); err != nil {
+		return fmt.Errorf("finish job %v: %w", job.ID, fenced(err))
+	}
+
+	return nil
+}
+
+// release is called on shutdown, when ctx of the worker is already cancelled.
+func (s *Service) release(job models.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.repo.ReleaseJob(ctx, job.ID, job.Attempts); err != nil {
+		s.log.Infof("cannot release job %v: %v", job.ID, fenced(err))
+	}
+}
+
+// fenced reports a write that found the job claimed by another worker as errLeaseLost.
+func fenced(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errLeaseLost
+	}
+
+	return err
+}
+
+// jobActor is the actor cars created by the job are audited with.
+func jobActor(jobID int) string {
+	return "job:" + strconv.Itoa(jobID)
+}
+
+// recoverCreated reports existing cars of createdIDs, which an interrupted attempt of the job
+// created, as created by the job, and the other ones as onConflict asks for.
+func recoverCreated(chunk *models.CreateCarsResult, createdIDs []int, onConflict string) {
+	recovered := make(map[string]int)
+	existing := make([]models.Car, 0, len(chunk.Existing))
+
+	for _, car := range chunk.Existing {
+		if !slices.Contains(createdIDs, car.ID) {
+			existing = append(existing, car)
+			continue
+		}
+
+		recovered[car.RegNum] = car.ID
+		chunk.Created = append(chunk.Created, car)
+		chunk.CreatedIDs = append(chunk.CreatedIDs, car.ID)
+	}
+
+	chunk.Existing = existing
+
+	if onConflict != domain.OnConflictReturn {
+		for _, car := range chunk.Existing {
+			chunk.Skipped = append(chunk.Skipped, car.RegNum)
+		}
+
+		chunk.Existing = nil
+	}
+
+	for i, plate := range chunk.Results {
+		if carID, ok := recovered[plate.RegNum]; ok && plate.Status == models.PlateStatusDuplicate && plate.CarID == carID {
+			chunk.Results[i] = models.PlateResult{RegNum: plate.RegNum, Status: models.PlateStatusCreated, CarID: carID}
+			continue
+		}
+
+		// skipped plates are reported without the car, as when the chunk was created with onConflict
+		if plate.Status == models.PlateStatusDuplicate && onConflict != domain.OnConflictReturn {
+			chunk.Results[i].CarID = 0
+		}
+	}
+}
+
+func mergeResults(result *models.CreateCarsResult, chunk models.CreateCarsResult) {
+	result.Created = append(result.Created, chunk.Created...)
+	result.Existing = append(result.Existing, chunk.Existing...)
+	result.Skipped = append(result.Skipped, chunk.Skipped...)
+	result.CreatedIDs = append(result.CreatedIDs, chunk.CreatedIDs...)
+	result.Results = append(result.Results, chunk.Results...)
+}
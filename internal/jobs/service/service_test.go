@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	repoMock "github.com/Verce11o/effective-mobile-test/internal/jobs/service/mocks"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/actor"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func plateResults(regNums []string) models.CreateCarsResult {
+	result := models.CreateCarsResult{}
+
+	for i, regNum := range regNums {
+		result.CreatedIDs = append(result.CreatedIDs, i+1)
+		result.Results = append(result.Results, models.PlateResult{RegNum: regNum, Status: models.PlateStatusCreated, CarID: i + 1})
+	}
+
+	return result
+}
+
+func TestService_ProcessNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		job        models.Job
+		input      domain.CreateCarsRequest
+		claimErr   error
+		createErr  error
+		wantChunks [][]string
+		wantFound  bool
+		wantStatus string
+	}{
+		{
+			name:      "empty queue",
+			claimErr:  pgx.ErrNoRows,
+			wantFound: false,
+		},
+		{
+			name:       "processed in chunks",
+			job:        models.Job{ID: 1, Attempts: 1},
+			input:      domain.CreateCarsRequest{RegNums: []string{"A1", "A2", "A3"}},
+			wantChunks: [][]string{{"A1", "A2"}, {"A3"}},
+			wantFound:  true,
+			wantStatus: models.JobStatusCompleted,
+		},
+		{
+			name:       "resumed after processed plates",
+			job:        models.Job{ID: 1, Attempts: 2, Processed: 2},
+			input:      domain.CreateCarsRequest{RegNums: []string{"A1", "A2", "A3"}},
+			wantChunks: [][]string{{"A3"}},
+			wantFound:  true,
+			wantStatus: models.JobStatusCompleted,
+		},
+		{
+			name:       "database error fails the job",
+			job:        models.Job{ID: 1, Attempts: 1},
+			input:      domain.CreateCarsRequest{RegNums: []string{"A1"}},
+			createErr:  errors.New("connection refused"),
+			wantChunks: [][]string{{"A1"}},
+			wantFound:  true,
+			wantStatus: models.JobStatusFailed,
+		},
+		{
+			name:       "too many attempts",
+			job:        models.Job{ID: 1, Attempts: 4},
+			input:      domain.CreateCarsRequest{RegNums: []string{"A1"}},
+			wantFound:  true,
+			wantStatus: models.JobStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repo := repoMock.NewRepository(t)
+			cars := repoMock.NewCarService(t)
+
+			repo.On("ClaimJob", mock.Anything, time.Minute).Return(tt.job, tt.input, tt.claimErr).Once()
+
+			for _, chunk := range tt.wantChunks {
+				repo.On("StartChunk", mock.Anything, tt.job.ID, tt.job.Attempts, mock.AnythingOfType("int"), time.Minute).
+					Return(nil).Once()
+				cars.On("CreateCar", mock.Anything, domain.CreateCarsRequest{RegNums: chunk, Partial: true}).
+					Return(plateResults(chunk), tt.createErr).Once()
+
+				if tt.createErr == nil {
+					repo.On("SaveProgress", mock.Anything, tt.job.ID, tt.job.Attempts, mock.AnythingOfType("int"),
+						mock.AnythingOfType("models.CreateCarsResult"), time.Minute).Return(nil).Once()
+				}
+			}
+
+			if tt.wantStatus != "" {
+				repo.On("FinishJob", mock.Anything, tt.job.ID, tt.job.Attempts, tt.wantStatus, mock.AnythingOfType("string")).
+					Return(nil).Once()
+			}
+
+			s := &Service{
+				log:    logger.NewMockLogger(),
+				repo:   repo,
+				cars:   cars,
+				tracer: tracer.InitTracer(ctx, "", ""),
+				opts:   Options{ChunkSize: 2, Lease: time.Minute, MaxAttempts: 3},
+			}
+
+			found, err := s.processNext(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFound, found)
+		})
+	}
+}
+
+func TestService_ProcessReleasesJobOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	repo := repoMock.NewRepository(t)
+	cars := repoMock.NewCarService(t)
+
+	cars.On("CreateCar", mock.Anything, mock.AnythingOfType("domain.CreateCarsRequest")).
+		Run(func(mock.Arguments) { cancel() }).
+		Return(models.CreateCarsResult{}, context.Canceled).Once()
+	repo.On("StartChunk", mock.Anything, 1, 1, 1, time.Minute).Return(nil).Once()
+	repo.On("ReleaseJob", mock.Anything, 1, 1).Return(nil).Once()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		cars:   cars,
+		tracer: tracer.InitTracer(ctx, "", ""),
+		opts:   Options{ChunkSize: 2, Lease: time.Minute},
+	}
+
+	err := s.process(ctx, models.Job{ID: 1, Attempts: 1}, domain.CreateCarsRequest{RegNums: []string{"A1"}})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestService_ProcessStopsWhenLeaseIsLost(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	cars := repoMock.NewCarService(t)
+
+	repo.On("StartChunk", mock.Anything, 1, 1, 2, time.Minute).Return(nil).Once()
+	cars.On("CreateCar", mock.Anything, domain.CreateCarsRequest{RegNums: []string{"A1", "A2"}, Partial: true}).
+		Return(plateResults([]string{"A1", "A2"}), nil).Once()
+	// the lease expired during the chunk and another worker claimed the job
+	repo.On("SaveProgress", mock.Anything, 1, 1, 2, mock.AnythingOfType("models.CreateCarsResult"), time.Minute).
+		Return(pgx.ErrNoRows).Once()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		cars:   cars,
+		tracer: tracer.InitTracer(ctx, "", ""),
+		opts:   Options{ChunkSize: 2, Lease: time.Minute},
+	}
+
+	err := s.process(ctx, models.Job{ID: 1, Attempts: 1}, domain.CreateCarsRequest{RegNums: []string{"A1", "A2", "A3"}})
+	require.ErrorIs(t, err, errLeaseLost)
+}
+
+func TestService_ProcessRecoversInterruptedChunk(t *testing.T) {
+	ctx := context.Background()
+
+	job := models.Job{ID: 1, Attempts: 2, Processed: 1, ChunkEnd: 3,
+		Result: &models.CreateCarsResult{
+			Created:    []models.Car{{ID: 1, RegNum: "A1"}},
+			CreatedIDs: []int{1},
+			Results:    []models.PlateResult{{RegNum: "A1", Status: models.PlateStatusCreated, CarID: 1}},
+		}}
+
+	repo := repoMock.NewRepository(t)
+	cars := repoMock.NewCarService(t)
+
+	// the interrupted chunk is created again as it was, with the chunk size of the previous attempt
+	repo.On("StartChunk", mock.Anything, 1, 2, 3, time.Minute).Return(nil).Once()
+	cars.On("CreateCar", mock.MatchedBy(func(ctx context.Context) bool {
+		return actor.FromContext(ctx) == "job:1"
+	}), domain.CreateCarsRequest{RegNums: []string{"A2", "A3"}, OnConflict: domain.OnConflictReturn, Partial: true}).
+		Return(models.CreateCarsResult{
+			Existing: []models.Car{{ID: 2, RegNum: "A2"}, {ID: 9, RegNum: "A3"}},
+			Results: []models.PlateResult{
+				{RegNum: "A2", Status: models.PlateStatusDuplicate, CarID: 2, Error: "car already exists"},
+				{RegNum: "A3", Status: models.PlateStatusDuplicate, CarID: 9, Error: "car already exists"},
+			},
+		}, nil).Once()
+	// A3 was created by someone else meanwhile, only the audit tells it from A2
+	repo.On("CarsCreatedBy", mock.Anything, "job:1", []int{2, 9}).Return([]int{2}, nil).Once()
+	repo.On("SaveProgress", mock.Anything, 1, 2, 3, models.CreateCarsResult{
+		Created:    []models.Car{{ID: 1, RegNum: "A1"}, {ID: 2, RegNum: "A2"}},
+		Skipped:    []string{"A3"},
+		CreatedIDs: []int{1, 2},
+		Results: []models.PlateResult{
+			{RegNum: "A1", Status: models.PlateStatusCreated, CarID: 1},
+			{RegNum: "A2", Status: models.PlateStatusCreated, CarID: 2},
+			{RegNum: "A3", Status: models.PlateStatusDuplicate, Error: "car already exists"},
+		},
+	}, time.Minute).Return(nil).Once()
+	repo.On("FinishJob", mock.Anything, 1, 2, models.JobStatusCompleted, "").Return(nil).Once()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		cars:   cars,
+		tracer: tracer.InitTracer(ctx, "", ""),
+		opts:   Options{ChunkSize: 5, Lease: time.Minute},
+	}
+
+	err := s.process(ctx, job, domain.CreateCarsRequest{RegNums: []string{"A1", "A2", "A3"}})
+	require.NoError(t, err)
+}
+
+func TestService_EnqueueCreateCars(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	repo.On("CreateJob", mock.Anything, domain.CreateCarsRequest{RegNums: []string{"A1"}}).
+		Return(models.Job{ID: 1, Status: models.JobStatusQueued, Total: 1}, nil).Once()
+
+	s := &Service{
+		log:    logger.NewMockLogger(),
+		repo:   repo,
+		tracer: tracer.InitTracer(ctx, "", ""),
+	}
+
+	job, err := s.EnqueueCreateCars(ctx, domain.CreateCarsRequest{RegNums: []string{"A1"}, Async: true})
+	require.NoError(t, err)
+	require.Equal(t, 1, job.ID)
+}
@@ -0,0 +1,42 @@
+package actor
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// Header names who makes the request. Requests are not authenticated, so it is taken on trust.
+const Header = "X-Actor"
+
+// maxLength cuts actors, so that a header can not bloat the audit log.
+const maxLength = 255
+
+type contextKey struct{}
+
+// WithActor returns ctx carrying the actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// FromContext returns the actor of ctx, empty when it is unknown.
+func FromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(contextKey{}).(string)
+	return actor
+}
+
+// Middleware puts the actor from Header into the request context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := strings.TrimSpace(c.GetHeader(Header))
+		if actor == "" {
+			return
+		}
+
+		if len(actor) > maxLength {
+			actor = strings.ToValidUTF8(actor[:maxLength], "")
+		}
+
+		c.Request = c.Request.WithContext(WithActor(c.Request.Context(), actor))
+	}
+}
@@ -0,0 +1,35 @@
+package actor
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "No actor", header: "", want: ""},
+		{name: "Actor", header: " alice ", want: "alice"},
+		{name: "Long actor", header: strings.Repeat("a", maxLength+10), want: strings.Repeat("a", maxLength)},
+		{name: "Cut inside a rune", header: strings.Repeat("a", maxLength-1) + "ж", want: strings.Repeat("a", maxLength-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			ctx.Request.Header.Set(Header, tt.header)
+
+			Middleware()(ctx)
+
+			assert.Equal(t, tt.want, FromContext(ctx.Request.Context()))
+		})
+	}
+}
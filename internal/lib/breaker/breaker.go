@@ -0,0 +1,129 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// Breaker opens after threshold consecutive failures and rejects calls until
+// openTimeout passes. Then a single probe call is let through: its success closes
+// the breaker again, its failure reopens it.
+type Breaker struct {
+	mu            sync.Mutex
+	state         State
+	failures      int
+	probing       bool
+	openedAt      time.Time
+	threshold     int
+	openTimeout   time.Duration
+	onStateChange func(from, to State)
+	now           func() time.Time
+}
+
+func New(threshold int, openTimeout time.Duration, onStateChange func(from, to State)) *Breaker {
+	return &Breaker{
+		threshold:     max(threshold, 1),
+		openTimeout:   openTimeout,
+		onStateChange: onStateChange,
+		now:           time.Now,
+	}
+}
+
+// Allow returns ErrOpen if the call must not be made. Every allowed call
+// has to be followed by Success, Failure or Cancel.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return ErrOpen
+		}
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	}
+
+	return nil
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+
+	if b.state != StateClosed {
+		b.setState(StateClosed)
+	}
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.failures++
+
+	if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.threshold) {
+		b.openedAt = b.now()
+		b.setState(StateOpen)
+	}
+}
+
+// Cancel ends an allowed call that tells nothing about the upstream, e.g. one cut short by the
+// caller. The state is kept, a cancelled probe lets the next call probe.
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+
+	if b.onStateChange != nil {
+		b.onStateChange(from, state)
+	}
+}
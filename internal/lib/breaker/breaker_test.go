@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(threshold int, openTimeout time.Duration) (*Breaker, *time.Time, *[]State) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	changes := make([]State, 0)
+
+	b := New(threshold, openTimeout, func(_, to State) {
+		changes = append(changes, to)
+	})
+	b.now = func() time.Time { return now }
+
+	return b, &now, &changes
+}
+
+// open fails allowed calls until the breaker opens.
+func open(t *testing.T, b *Breaker) {
+	t.Helper()
+
+	for b.State() != StateOpen {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+}
+
+func TestBreaker_OpensAtThreshold(t *testing.T) {
+	b, _, changes := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+
+	require.Equal(t, StateClosed, b.State())
+
+	// a success resets the count of consecutive failures
+	require.NoError(t, b.Allow())
+	b.Success()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+
+	require.Equal(t, StateClosed, b.State())
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+
+	require.Equal(t, StateOpen, b.State())
+	require.ErrorIs(t, b.Allow(), ErrOpen)
+	require.Equal(t, []State{StateOpen}, *changes)
+}
+
+func TestBreaker_HalfOpenAfterTimeout(t *testing.T) {
+	b, now, changes := newTestBreaker(1, time.Minute)
+
+	open(t, b)
+
+	*now = now.Add(time.Minute - time.Second)
+	require.ErrorIs(t, b.Allow(), ErrOpen)
+	require.Equal(t, StateOpen, b.State())
+
+	*now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+	require.Equal(t, StateHalfOpen, b.State())
+	require.Equal(t, []State{StateOpen, StateHalfOpen}, *changes)
+}
+
+func TestBreaker_SingleProbe(t *testing.T) {
+	b, now, _ := newTestBreaker(1, time.Minute)
+
+	open(t, b)
+	*now = now.Add(time.Minute)
+
+	require.NoError(t, b.Allow())
+	require.ErrorIs(t, b.Allow(), ErrOpen)
+	require.ErrorIs(t, b.Allow(), ErrOpen)
+
+	// a cancelled probe keeps the state and lets the next call probe
+	b.Cancel()
+	require.Equal(t, StateHalfOpen, b.State())
+
+	require.NoError(t, b.Allow())
+	require.ErrorIs(t, b.Allow(), ErrOpen)
+}
+
+func TestBreaker_HalfOpenOutcomes(t *testing.T) {
+	tests := []struct {
+		name      string
+		outcome   func(b *Breaker)
+		wantState State
+		wantAllow error
+	}{
+		{
+			name:      "success closes",
+			outcome:   (*Breaker).Success,
+			wantState: StateClosed,
+		},
+		{
+			name:      "failure reopens",
+			outcome:   (*Breaker).Failure,
+			wantState: StateOpen,
+			wantAllow: ErrOpen,
+		},
+		{
+			name:      "cancel stays half-open",
+			outcome:   (*Breaker).Cancel,
+			wantState: StateHalfOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now, _ := newTestBreaker(2, time.Minute)
+
+			open(t, b)
+			*now = now.Add(time.Minute)
+
+			require.NoError(t, b.Allow())
+			tt.outcome(b)
+
+			require.Equal(t, tt.wantState, b.State())
+			require.ErrorIs(t, b.Allow(), tt.wantAllow)
+		})
+	}
+}
+
+func TestBreaker_ReopenRestartsTimeout(t *testing.T) {
+	b, now, _ := newTestBreaker(1, time.Minute)
+
+	open(t, b)
+	*now = now.Add(time.Minute)
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+
+	*now = now.Add(time.Minute - time.Second)
+	require.ErrorIs(t, b.Allow(), ErrOpen)
+
+	*now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+}
@@ -0,0 +1,145 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"time"
+)
+
+type CarInfoGetter interface {
+	GetCarInfo(ctx context.Context, regNum string) (domain.Car, error)
+}
+
+type cachedCarInfo struct {
+	Found bool       `json:"found"`
+	Car   domain.Car `json:"car"`
+}
+
+// CachedCommunicator stores results of the wrapped CarInfoGetter in redis.
+// Unknown plates are cached as well, with their own ttl. Concurrent lookups
+// of one plate share a single upstream call limited by lookupTimeout.
+type CachedCommunicator struct {
+	next          CarInfoGetter
+	client        *redis.Client
+	log           *zap.SugaredLogger
+	tracer        trace.Tracer
+	ttl           time.Duration
+	notFoundTTL   time.Duration
+	lookupTimeout time.Duration
+	group         singleflight.Group
+}
+
+func NewCachedCommunicator(next CarInfoGetter, client *redis.Client, log *zap.SugaredLogger, tracer trace.Tracer, ttl, notFoundTTL,
+	lookupTimeout time.Duration) *CachedCommunicator {
+	return &CachedCommunicator{next: next, client: client, log: log, tracer: tracer, ttl: ttl, notFoundTTL: notFoundTTL,
+		lookupTimeout: lookupTimeout}
+}
+
+func (c *CachedCommunicator) GetCarInfo(ctx context.Context, regNum string) (domain.Car, error) {
+	ctx, span := c.tracer.Start(ctx, "carInfoCache.GetCarInfo")
+	defer span.End()
+
+	cached, err := c.get(ctx, regNum)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		c.log.Debugf("cannot get car info %v in redis: %v", regNum, err)
+	}
+
+	if cached != nil {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+
+		if !cached.Found {
+			return domain.Car{}, response.ErrCarInfoNotFound
+		}
+		return cached.Car, nil
+	}
+
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+
+	// the shared lookup must not be cancelled when the caller that started it goes away,
+	// every caller stops waiting on its own context instead. It gets a deadline of its own,
+	// so that it can not outlive all of them by far.
+	ch := c.group.DoChan(regNum, func() (any, error) {
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.lookupTimeout)
+		defer cancel()
+
+		return c.lookup(lookupCtx, regNum)
+	})
+
+	select {
+	case <-ctx.Done():
+		return domain.Car{}, fmt.Errorf("%w: %w", response.ErrGettingCarInfo, ctx.Err())
+	case res := <-ch:
+		span.SetAttributes(attribute.Bool("lookup.shared", res.Shared))
+
+		if res.Err != nil {
+			return domain.Car{}, res.Err
+		}
+		return res.Val.(domain.Car), nil
+	}
+}
+
+// Evict removes cached lookup result of regNum.
+func (c *CachedCommunicator) Evict(ctx context.Context, regNum string) error {
+	ctx, span := c.tracer.Start(ctx, "carInfoCache.Evict")
+	defer span.End()
+
+	return c.client.Del(ctx, c.createKey(regNum)).Err()
+}
+
+func (c *CachedCommunicator) lookup(ctx context.Context, regNum string) (domain.Car, error) {
+	car, err := c.next.GetCarInfo(ctx, regNum)
+
+	switch {
+	case err == nil:
+		c.set(ctx, regNum, cachedCarInfo{Found: true, Car: car}, c.ttl)
+	case errors.Is(err, response.ErrCarInfoNotFound):
+		c.set(ctx, regNum, cachedCarInfo{Found: false}, c.notFoundTTL)
+	}
+
+	return car, err
+}
+
+func (c *CachedCommunicator) get(ctx context.Context, regNum string) (*cachedCarInfo, error) {
+	infoBytes, err := c.client.Get(ctx, c.createKey(regNum)).Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var info cachedCarInfo
+
+	if err = json.Unmarshal(infoBytes, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+func (c *CachedCommunicator) set(ctx context.Context, regNum string, info cachedCarInfo, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		c.log.Infof("cannot marshal car info %v: %v", regNum, err)
+		return
+	}
+
+	if err = c.client.Set(ctx, c.createKey(regNum), infoBytes, ttl).Err(); err != nil {
+		c.log.Infof("cannot set car info %v in redis: %v", regNum, err)
+	}
+}
+
+func (c *CachedCommunicator) createKey(regNum string) string {
+	return fmt.Sprintf("carinfo:%s", regNum)
+}
@@ -0,0 +1,221 @@
+package communicator
+
+import (
+	"context"
+	"errors"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubGetter answers lookups with get and counts them.
+type stubGetter struct {
+	calls atomic.Int32
+	get   func(ctx context.Context, regNum string) (domain.Car, error)
+}
+
+func (s *stubGetter) GetCarInfo(ctx context.Context, regNum string) (domain.Car, error) {
+	s.calls.Add(1)
+	return s.get(ctx, regNum)
+}
+
+func newTestCache(t *testing.T, next CarInfoGetter, ttl, notFoundTTL, lookupTimeout time.Duration) (*CachedCommunicator, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	c := NewCachedCommunicator(next, client, logger.NewMockLogger(), tracer.InitTracer(context.Background(), "", ""),
+		ttl, notFoundTTL, lookupTimeout)
+
+	return c, mr
+}
+
+func TestCachedCommunicator_GetCarInfo(t *testing.T) {
+	upstreamErr := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		upstreamErr error
+		notFoundTTL time.Duration
+		wantErr     error
+		wantTTL     time.Duration
+	}{
+		{
+			name:    "found car is cached with ttl",
+			wantTTL: time.Hour,
+		},
+		{
+			name:        "unknown plate is cached with not found ttl",
+			upstreamErr: response.ErrCarInfoNotFound,
+			notFoundTTL: time.Minute,
+			wantErr:     response.ErrCarInfoNotFound,
+			wantTTL:     time.Minute,
+		},
+		{
+			name:        "zero not found ttl disables negative caching",
+			upstreamErr: response.ErrCarInfoNotFound,
+			wantErr:     response.ErrCarInfoNotFound,
+		},
+		{
+			name:        "other errors are not cached",
+			upstreamErr: upstreamErr,
+			notFoundTTL: time.Minute,
+			wantErr:     upstreamErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &stubGetter{get: func(_ context.Context, regNum string) (domain.Car, error) {
+				if tt.upstreamErr != nil {
+					return domain.Car{}, tt.upstreamErr
+				}
+
+				return domain.Car{RegNum: regNum, Mark: "Lada"}, nil
+			}}
+
+			c, mr := newTestCache(t, next, time.Hour, tt.notFoundTTL, time.Second)
+
+			for i := 0; i < 2; i++ {
+				car, err := c.GetCarInfo(context.Background(), "X123XX150")
+				require.ErrorIs(t, err, tt.wantErr)
+
+				if tt.wantErr == nil {
+					require.Equal(t, domain.Car{RegNum: "X123XX150", Mark: "Lada"}, car)
+				}
+			}
+
+			if tt.wantTTL == 0 {
+				require.False(t, mr.Exists("carinfo:X123XX150"))
+				require.EqualValues(t, 2, next.calls.Load())
+				return
+			}
+
+			require.Equal(t, tt.wantTTL, mr.TTL("carinfo:X123XX150"))
+			require.EqualValues(t, 1, next.calls.Load())
+
+			// the cached result expires with its ttl
+			mr.FastForward(tt.wantTTL)
+
+			_, err := c.GetCarInfo(context.Background(), "X123XX150")
+			require.ErrorIs(t, err, tt.wantErr)
+			require.EqualValues(t, 2, next.calls.Load())
+		})
+	}
+}
+
+func TestCachedCommunicator_SharesLookup(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	next := &stubGetter{get: func(_ context.Context, regNum string) (domain.Car, error) {
+		started <- struct{}{}
+		<-release
+
+		return domain.Car{RegNum: regNum}, nil
+	}}
+
+	// results are not cached, so that only the shared lookup keeps callers from the upstream
+	c, _ := newTestCache(t, next, 0, 0, time.Second)
+
+	const callers = 5
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, callers)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.GetCarInfo(context.Background(), "X123XX150")
+			errs <- err
+		}()
+	}
+
+	<-started
+	// the other callers join the lookup in flight meanwhile
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.EqualValues(t, 1, next.calls.Load())
+}
+
+func TestCachedCommunicator_CallerGoesAway(t *testing.T) {
+	release := make(chan struct{})
+
+	next := &stubGetter{get: func(_ context.Context, regNum string) (domain.Car, error) {
+		<-release
+		return domain.Car{RegNum: regNum}, nil
+	}}
+
+	c, mr := newTestCache(t, next, time.Hour, time.Minute, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.GetCarInfo(ctx, "X123XX150")
+	require.ErrorIs(t, err, response.ErrGettingCarInfo)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// the lookup goes on without the caller and caches its result for the next one
+	close(release)
+
+	require.Eventually(t, func() bool { return mr.Exists("carinfo:X123XX150") }, time.Second, 5*time.Millisecond)
+
+	car, err := c.GetCarInfo(context.Background(), "X123XX150")
+	require.NoError(t, err)
+	require.Equal(t, "X123XX150", car.RegNum)
+	require.EqualValues(t, 1, next.calls.Load())
+}
+
+func TestCachedCommunicator_LookupTimeout(t *testing.T) {
+	next := &stubGetter{get: func(ctx context.Context, _ string) (domain.Car, error) {
+		<-ctx.Done()
+		return domain.Car{}, ctx.Err()
+	}}
+
+	c, _ := newTestCache(t, next, time.Hour, time.Minute, 20*time.Millisecond)
+
+	// the caller waits without a deadline, the shared lookup still ends
+	_, err := c.GetCarInfo(context.Background(), "X123XX150")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCachedCommunicator_Evict(t *testing.T) {
+	next := &stubGetter{get: func(_ context.Context, regNum string) (domain.Car, error) {
+		return domain.Car{RegNum: regNum}, nil
+	}}
+
+	c, mr := newTestCache(t, next, time.Hour, time.Minute, time.Second)
+
+	_, err := c.GetCarInfo(context.Background(), "X123XX150")
+	require.NoError(t, err)
+	require.True(t, mr.Exists("carinfo:X123XX150"))
+
+	require.NoError(t, c.Evict(context.Background(), "X123XX150"))
+	require.False(t, mr.Exists("carinfo:X123XX150"))
+
+	_, err = c.GetCarInfo(context.Background(), "X123XX150")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, next.calls.Load())
+}
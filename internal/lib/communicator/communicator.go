@@ -1,34 +1,146 @@
 package communicator
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/config"
 	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/breaker"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
 	"time"
 )
 
+// errTransient marks upstream failures that are worth retrying.
+var errTransient = errors.New("transient error")
+
 type Communicator struct {
+	log         *zap.SugaredLogger
+	tracer      trace.Tracer
 	apiEndpoint string
+	client      *http.Client
+	limiter     *rate.Limiter
+	breaker     *breaker.Breaker
+	cfg         config.ExternalCarsApiResilience
+}
+
+func NewCommunicator(log *zap.SugaredLogger, tracer trace.Tracer, cfg config.ExternalCarsApi) *Communicator {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if cfg.Resilience.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.Resilience.RateLimit), max(cfg.Resilience.RateBurst, 1))
+	}
+
+	onStateChange := func(from, to breaker.State) {
+		log.Warnf("external cars api circuit breaker: %v -> %v", from, to)
+	}
+
+	return &Communicator{
+		log:         log,
+		tracer:      tracer,
+		apiEndpoint: cfg.URL,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		limiter:     limiter,
+		breaker:     breaker.New(cfg.Resilience.BreakerThreshold, cfg.Resilience.BreakerOpenTimeout, onStateChange),
+		cfg:         cfg.Resilience,
+	}
 }
 
-func NewCommunicator(apiEndpoint string) *Communicator {
-	return &Communicator{apiEndpoint: apiEndpoint}
+// GetCarInfo looks regNum up in the external api retrying transient failures
+// with jittered exponential backoff. While the circuit breaker is open the call
+// fails immediately.
+func (c *Communicator) GetCarInfo(ctx context.Context, regNum string) (domain.Car, error) {
+	ctx, span := c.tracer.Start(ctx, "carCommunicator.GetCarInfo")
+	defer span.End()
+
+	var err error
+
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt)))
+
+			if err = sleep(ctx, c.backoff(attempt)); err != nil {
+				break
+			}
+		}
+
+		var car domain.Car
+
+		car, err = c.try(ctx, regNum)
+		span.SetAttributes(attribute.String("breaker.state", c.breaker.State().String()))
+
+		if err == nil {
+			return car, nil
+		}
+
+		if !errors.Is(err, errTransient) {
+			break
+		}
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	return domain.Car{}, err
 }
 
-func (c *Communicator) GetCarInfo(regNum string) (domain.Car, error) {
-	client := http.Client{Timeout: 3 * time.Second}
+func (c *Communicator) try(ctx context.Context, regNum string) (domain.Car, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return domain.Car{}, fmt.Errorf("%w: %w", response.ErrGettingCarInfo, err)
+	}
 
-	resp, err := client.Get(c.apiEndpoint + "/info?regNum=" + regNum)
+	if err := c.breaker.Allow(); err != nil {
+		return domain.Car{}, fmt.Errorf("%w: %w", response.ErrGettingCarInfo, err)
+	}
 
+	car, err := c.fetch(ctx, regNum)
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// the caller gave up, the upstream is not to blame
+		c.breaker.Cancel()
+	case errors.Is(err, errTransient):
+		c.breaker.Failure()
+	default:
+		// the upstream answered, even if the plate itself was rejected
+		c.breaker.Success()
+	}
+
+	return car, err
+}
+
+func (c *Communicator) fetch(ctx context.Context, regNum string) (domain.Car, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiEndpoint+"/info?regNum="+url.QueryEscape(regNum), nil)
 	if err != nil {
 		return domain.Car{}, err
 	}
 
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return domain.Car{}, fmt.Errorf("%w: %w: %w", response.ErrGettingCarInfo, errTransient, err)
+	}
+
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return domain.Car{}, response.ErrGettingCarInfo
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusBadRequest:
+		return domain.Car{}, response.ErrInvalidRegNum
+	case resp.StatusCode == http.StatusNotFound:
+		return domain.Car{}, response.ErrCarInfoNotFound
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return domain.Car{}, fmt.Errorf("%w: %w: unexpected status %d", response.ErrGettingCarInfo, errTransient, resp.StatusCode)
+	default:
+		return domain.Car{}, fmt.Errorf("%w: unexpected status %d", response.ErrGettingCarInfo, resp.StatusCode)
 	}
 
 	var car domain.Car
@@ -40,3 +152,29 @@ func (c *Communicator) GetCarInfo(regNum string) (domain.Car, error) {
 
 	return car, nil
 }
+
+// backoff returns full jitter delay for the given retry attempt.
+func (c *Communicator) backoff(attempt int) time.Duration {
+	delay := c.cfg.RetryMaxDelay
+	if shift := attempt - 1; shift < 32 {
+		delay = min(c.cfg.RetryBaseDelay<<shift, c.cfg.RetryMaxDelay)
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return rand.N(delay)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", response.ErrGettingCarInfo, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
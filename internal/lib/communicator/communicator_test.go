@@ -0,0 +1,167 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Verce11o/effective-mobile-test/internal/config"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/breaker"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCommunicator(t *testing.T, url string, resilience config.ExternalCarsApiResilience) *Communicator {
+	t.Helper()
+
+	return NewCommunicator(logger.NewMockLogger(), tracer.InitTracer(context.Background(), "", ""), config.ExternalCarsApi{
+		URL:        url,
+		Timeout:    time.Second,
+		Resilience: resilience,
+	})
+}
+
+func TestCommunicator_GetCarInfo(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   error
+	}{
+		{
+			name:      "success",
+			statuses:  []int{http.StatusOK},
+			wantCalls: 1,
+		},
+		{
+			name:      "retry transient errors",
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			wantCalls: 3,
+		},
+		{
+			name:      "give up after max retries",
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantCalls: 3,
+			wantErr:   response.ErrGettingCarInfo,
+		},
+		{
+			name:      "not found is not retried",
+			statuses:  []int{http.StatusNotFound},
+			wantCalls: 1,
+			wantErr:   response.ErrCarInfoNotFound,
+		},
+		{
+			name:      "invalid reg num is not retried",
+			statuses:  []int{http.StatusBadRequest},
+			wantCalls: 1,
+			wantErr:   response.ErrInvalidRegNum,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := calls.Add(1)
+				status := tt.statuses[min(int(call), len(tt.statuses))-1]
+
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+
+				_ = json.NewEncoder(w).Encode(domain.Car{RegNum: r.URL.Query().Get("regNum")})
+			}))
+			defer srv.Close()
+
+			c := newTestCommunicator(t, srv.URL, config.ExternalCarsApiResilience{
+				MaxRetries:         2,
+				RetryBaseDelay:     time.Millisecond,
+				RetryMaxDelay:      5 * time.Millisecond,
+				BreakerThreshold:   10,
+				BreakerOpenTimeout: time.Minute,
+			})
+
+			car, err := c.GetCarInfo(context.Background(), "X123XX150")
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.wantCalls, calls.Load())
+
+			if tt.wantErr == nil {
+				require.Equal(t, "X123XX150", car.RegNum)
+			}
+		})
+	}
+}
+
+func TestCommunicator_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := newTestCommunicator(t, srv.URL, config.ExternalCarsApiResilience{
+		BreakerThreshold:   2,
+		BreakerOpenTimeout: time.Minute,
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetCarInfo(context.Background(), "X123XX150")
+		require.ErrorIs(t, err, response.ErrGettingCarInfo)
+	}
+
+	require.Equal(t, breaker.StateOpen, c.breaker.State())
+
+	_, err := c.GetCarInfo(context.Background(), "X123XX150")
+	require.ErrorIs(t, err, breaker.ErrOpen)
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestCommunicator_CancelledProbe(t *testing.T) {
+	var calls atomic.Int32
+
+	done := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	c := newTestCommunicator(t, srv.URL, config.ExternalCarsApiResilience{
+		BreakerThreshold:   1,
+		BreakerOpenTimeout: time.Millisecond,
+	})
+
+	_, err := c.GetCarInfo(context.Background(), "X123XX150")
+	require.ErrorIs(t, err, response.ErrGettingCarInfo)
+	require.Equal(t, breaker.StateOpen, c.breaker.State())
+
+	time.Sleep(2 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// the probe runs out of the deadline of the caller, which tells nothing about the upstream
+	_, err = c.GetCarInfo(ctx, "X123XX150")
+	require.Error(t, err)
+	require.Equal(t, breaker.StateHalfOpen, c.breaker.State())
+	require.NoError(t, c.breaker.Allow())
+}
@@ -0,0 +1,304 @@
+package pagination
+
+import (
+	"encoding/json"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"slices"
+	"strings"
+)
+
+// SortKey is one field of a sort order.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses comma separated fields, a field prefixed with "-" is sorted in descending order.
+func ParseSort(sort string) ([]SortKey, error) {
+	if sort == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(sort, ",")
+	keys := make([]SortKey, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+
+	for _, field := range fields {
+		key := SortKey{Field: strings.TrimSpace(field)}
+
+		if strings.HasPrefix(key.Field, "-") {
+			key.Field = key.Field[1:]
+			key.Desc = true
+		}
+
+		if key.Field == "" {
+			return nil, fmt.Errorf("empty sort field in %q", sort)
+		}
+
+		if _, ok := seen[key.Field]; ok {
+			return nil, fmt.Errorf("duplicate sort field %q", key.Field)
+		}
+
+		seen[key.Field] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// FormatSort is the inverse of ParseSort.
+func FormatSort(keys []SortKey) string {
+	fields := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if key.Desc {
+			fields = append(fields, "-"+key.Field)
+			continue
+		}
+
+		fields = append(fields, key.Field)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// Column is a field rows of type T can be sorted by.
+type Column[T any] struct {
+	// Expr is compared with cursor values, nullable columns should be coalesced to keep the comparison total.
+	Expr string
+	// Value returns the field of the row stored in the cursor.
+	Value func(row T) any
+	// Dst returns a pointer the cursor value of the field is decoded into.
+	Dst func() any
+}
+
+// Keyset paginates rows of type T sorted by any combination of its columns.
+type Keyset[T any] struct {
+	paginator   *Paginator
+	columns     map[string]Column[T]
+	defaultSort []SortKey
+	tiebreaker  string
+}
+
+// NewKeyset creates a Keyset. The tiebreaker column must be unique, it is appended
+// to every sort order that lacks it, so that the order is total.
+func NewKeyset[T any](paginator *Paginator, columns map[string]Column[T], defaultSort []SortKey, tiebreaker string) *Keyset[T] {
+	return &Keyset[T]{paginator: paginator, columns: columns, defaultSort: defaultSort, tiebreaker: tiebreaker}
+}
+
+// Request describes the requested page.
+type Request struct {
+	Cursor string
+	Sort   string
+	Limit  int
+	// Filter is anything identifying the filters of the query, a cursor is only valid with the same filters.
+	Filter any
+}
+
+// Page holds cursors of the returned page. HasMore reports whether there are rows beyond it
+// in the direction it was requested in.
+type Page struct {
+	Next    string
+	Prev    string
+	HasMore bool
+}
+
+// Plan is a validated page request.
+type Plan[T any] struct {
+	keyset    *Keyset[T]
+	keys      []SortKey
+	sort      string
+	filter    string
+	direction string
+	values    []any
+	limit     int
+}
+
+func (k *Keyset[T]) Plan(req Request) (*Plan[T], error) {
+	keys, err := ParseSort(req.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		keys = slices.Clone(k.defaultSort)
+	}
+
+	hasTiebreaker := false
+
+	for _, key := range keys {
+		if _, ok := k.columns[key.Field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q", key.Field)
+		}
+
+		hasTiebreaker = hasTiebreaker || key.Field == k.tiebreaker
+	}
+
+	if !hasTiebreaker {
+		keys = append(keys, SortKey{Field: k.tiebreaker})
+	}
+
+	limit, err := k.paginator.Limit(req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := Fingerprint(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan[T]{
+		keyset:    k,
+		keys:      keys,
+		sort:      FormatSort(keys),
+		filter:    filter,
+		direction: DirectionNext,
+		limit:     limit,
+	}
+
+	if req.Cursor == "" {
+		return plan, nil
+	}
+
+	c, err := k.paginator.decode(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Sort != plan.sort || c.Filter != plan.filter || len(c.Values) != len(keys) {
+		return nil, fmt.Errorf("%w: cursor was created for another query", ErrInvalidCursor)
+	}
+
+	plan.direction = c.Direction
+	plan.values = make([]any, 0, len(keys))
+
+	for i, key := range keys {
+		dst := k.columns[key.Field].Dst()
+
+		if err = json.Unmarshal(c.Values[i], dst); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		}
+
+		plan.values = append(plan.values, dst)
+	}
+
+	return plan, nil
+}
+
+// Keys returns the sort order of the page, including the tiebreaker.
+func (p *Plan[T]) Keys() []SortKey {
+	return slices.Clone(p.keys)
+}
+
+// Apply adds ordering, cursor condition and limit to query. One extra row is selected
+// to find out whether there are more rows.
+func (p *Plan[T]) Apply(query sq.SelectBuilder) sq.SelectBuilder {
+	// previous page is selected in reversed order and flipped back in Page
+	reverse := p.direction == DirectionPrev
+
+	orderBy := make([]string, 0, len(p.keys))
+
+	for _, key := range p.keys {
+		direction := "ASC"
+		if key.Desc != reverse {
+			direction = "DESC"
+		}
+
+		orderBy = append(orderBy, p.keyset.columns[key.Field].Expr+" "+direction)
+	}
+
+	query = query.OrderBy(orderBy...).Limit(uint64(p.limit + 1))
+
+	if p.values != nil {
+		query = query.Where(p.after(reverse))
+	}
+
+	return query
+}
+
+// after matches rows placed after the cursor in the order of the query:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func (p *Plan[T]) after(reverse bool) sq.Or {
+	pred := make(sq.Or, 0, len(p.keys))
+
+	for i, key := range p.keys {
+		and := make(sq.And, 0, i+1)
+
+		for j := 0; j < i; j++ {
+			and = append(and, sq.Expr(p.keyset.columns[p.keys[j].Field].Expr+" = ?", p.values[j]))
+		}
+
+		op := " > ?"
+		if key.Desc != reverse {
+			op = " < ?"
+		}
+
+		and = append(and, sq.Expr(p.keyset.columns[key.Field].Expr+op, p.values[i]))
+		pred = append(pred, and)
+	}
+
+	return pred
+}
+
+// Page trims rows selected by a query built with Apply to the page size and creates cursors of the page.
+func (p *Plan[T]) Page(rows []T) ([]T, Page, error) {
+	var page Page
+
+	page.HasMore = len(rows) > p.limit
+	if page.HasMore {
+		rows = rows[:p.limit]
+	}
+
+	if p.direction == DirectionPrev {
+		slices.Reverse(rows)
+	}
+
+	if len(rows) == 0 {
+		return rows, page, nil
+	}
+
+	// a page reached backwards always has a next one, the page it was reached from,
+	// and a page reached forwards has a previous one unless it is the first page
+	hasNext, hasPrev := page.HasMore, p.values != nil
+	if p.direction == DirectionPrev {
+		hasNext, hasPrev = true, page.HasMore
+	}
+
+	var err error
+
+	if hasNext {
+		if page.Next, err = p.cursor(DirectionNext, rows[len(rows)-1]); err != nil {
+			return nil, Page{}, err
+		}
+	}
+
+	if hasPrev {
+		if page.Prev, err = p.cursor(DirectionPrev, rows[0]); err != nil {
+			return nil, Page{}, err
+		}
+	}
+
+	return rows, page, nil
+}
+
+func (p *Plan[T]) cursor(direction string, row T) (string, error) {
+	c := cursor{
+		Direction: direction,
+		Sort:      p.sort,
+		Filter:    p.filter,
+		Values:    make([]json.RawMessage, 0, len(p.keys)),
+	}
+
+	for _, key := range p.keys {
+		valueBytes, err := json.Marshal(p.keyset.columns[key.Field].Value(row))
+		if err != nil {
+			return "", err
+		}
+
+		c.Values = append(c.Values, valueBytes)
+	}
+
+	return p.keyset.paginator.encode(c)
+}
@@ -0,0 +1,190 @@
+package pagination
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testRow struct {
+	ID   int
+	Year int
+}
+
+var testColumns = map[string]Column[testRow]{
+	"id": {
+		Expr:  "id",
+		Value: func(row testRow) any { return row.ID },
+		Dst:   func() any { return new(int) },
+	},
+	"year": {
+		Expr:  "year",
+		Value: func(row testRow) any { return row.Year },
+		Dst:   func() any { return new(int) },
+	},
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		want    []SortKey
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			sort: "",
+		},
+		{
+			name: "mixed directions",
+			sort: "-year,mark",
+			want: []SortKey{{Field: "year", Desc: true}, {Field: "mark"}},
+		},
+		{
+			name:    "empty field",
+			sort:    "year,,mark",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate field",
+			sort:    "year,-year",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseSort(tt.sort)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, keys)
+		})
+	}
+}
+
+func TestKeyset_Apply(t *testing.T) {
+	keyset := NewKeyset(NewPaginator("secret", 2, 5), testColumns, nil, "id")
+
+	plan, err := keyset.Plan(Request{Sort: "-year"})
+	require.NoError(t, err)
+
+	_, page, err := plan.Page([]testRow{{ID: 1, Year: 2020}, {ID: 2, Year: 2019}, {ID: 3, Year: 2019}})
+	require.NoError(t, err)
+
+	plan, err = keyset.Plan(Request{Sort: "-year", Cursor: page.Next})
+	require.NoError(t, err)
+
+	sql, args, err := plan.Apply(sq.Select("*").From("rows")).ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM rows WHERE ((year < ?) OR (year = ? AND id > ?)) ORDER BY year DESC, id ASC LIMIT 3", sql)
+	require.Len(t, args, 3)
+
+	_, page, err = plan.Page([]testRow{{ID: 3, Year: 2019}})
+	require.NoError(t, err)
+
+	plan, err = keyset.Plan(Request{Sort: "-year", Cursor: page.Prev})
+	require.NoError(t, err)
+
+	sql, _, err = plan.Apply(sq.Select("*").From("rows")).ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM rows WHERE ((year > ?) OR (year = ? AND id < ?)) ORDER BY year ASC, id DESC LIMIT 3", sql)
+}
+
+func TestKeyset_Page(t *testing.T) {
+	keyset := NewKeyset(NewPaginator("secret", 2, 5), testColumns, nil, "id")
+
+	plan, err := keyset.Plan(Request{})
+	require.NoError(t, err)
+
+	rows, page, err := plan.Page([]testRow{{ID: 1}, {ID: 2}, {ID: 3}})
+	require.NoError(t, err)
+	require.Equal(t, []testRow{{ID: 1}, {ID: 2}}, rows)
+	require.True(t, page.HasMore)
+	require.NotEmpty(t, page.Next)
+	require.Empty(t, page.Prev)
+
+	plan, err = keyset.Plan(Request{Cursor: page.Next})
+	require.NoError(t, err)
+
+	rows, page, err = plan.Page([]testRow{{ID: 3}})
+	require.NoError(t, err)
+	require.Equal(t, []testRow{{ID: 3}}, rows)
+	require.False(t, page.HasMore)
+	require.Empty(t, page.Next)
+	require.NotEmpty(t, page.Prev)
+
+	// previous page is selected in reversed order
+	plan, err = keyset.Plan(Request{Cursor: page.Prev})
+	require.NoError(t, err)
+
+	rows, page, err = plan.Page([]testRow{{ID: 2}, {ID: 1}})
+	require.NoError(t, err)
+	require.Equal(t, []testRow{{ID: 1}, {ID: 2}}, rows)
+	require.False(t, page.HasMore)
+	require.NotEmpty(t, page.Next)
+	require.Empty(t, page.Prev)
+}
+
+func TestKeyset_Plan(t *testing.T) {
+	keyset := NewKeyset(NewPaginator("secret", 2, 5), testColumns, nil, "id")
+
+	plan, err := keyset.Plan(Request{Filter: "mark=BMW"})
+	require.NoError(t, err)
+
+	_, page, err := plan.Page([]testRow{{ID: 1}, {ID: 2}, {ID: 3}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		keyset  *Keyset[testRow]
+		req     Request
+		wantErr error
+	}{
+		{
+			name: "same query",
+			req:  Request{Cursor: page.Next, Filter: "mark=BMW"},
+		},
+		{
+			name:    "another filter",
+			req:     Request{Cursor: page.Next, Filter: "mark=Lada"},
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "another sort",
+			req:     Request{Cursor: page.Next, Sort: "-id", Filter: "mark=BMW"},
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "tampered cursor",
+			req:     Request{Cursor: page.Next[:len(page.Next)-2] + "AA", Filter: "mark=BMW"},
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "signed with another secret",
+			keyset:  NewKeyset(NewPaginator("another", 2, 5), testColumns, nil, "id"),
+			req:     Request{Cursor: page.Next, Filter: "mark=BMW"},
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "limit above maximum",
+			req:     Request{Limit: 6},
+			wantErr: ErrInvalidLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := keyset
+			if tt.keyset != nil {
+				k = tt.keyset
+			}
+
+			_, err := k.Plan(tt.req)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -1,26 +1,125 @@
 package pagination
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
+	"strings"
 )
 
-func DecodeCursor(encodedCursor string) (int, error) {
-	bytes, err := base64.StdEncoding.DecodeString(encodedCursor)
+const cursorVersion = "v1"
+
+const (
+	DirectionNext = "next"
+	DirectionPrev = "prev"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Paginator signs cursors and limits page sizes.
+type Paginator struct {
+	secret       []byte
+	defaultLimit int
+	maxLimit     int
+}
+
+// NewPaginator creates a Paginator signing cursors with secret. With an empty secret
+// a random one is used, so cursors do not survive restarts and are not accepted by other instances.
+func NewPaginator(secret string, defaultLimit, maxLimit int) *Paginator {
+	key := []byte(secret)
+
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+
+	return &Paginator{secret: key, defaultLimit: defaultLimit, maxLimit: max(maxLimit, defaultLimit)}
+}
+
+// Limit returns the page size for requested limit, zero means the default one.
+func (p *Paginator) Limit(limit int) (int, error) {
+	if limit == 0 {
+		return p.defaultLimit, nil
+	}
+
+	if limit < 0 || limit > p.maxLimit {
+		return 0, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidLimit, p.maxLimit)
+	}
+
+	return limit, nil
+}
+
+// cursor is the signed payload. Sort and Filter bind it to the query it was created for.
+type cursor struct {
+	Direction string            `json:"d"`
+	Sort      string            `json:"s"`
+	Filter    string            `json:"f"`
+	Values    []json.RawMessage `json:"v"`
+}
+
+func (p *Paginator) encode(c cursor) (string, error) {
+	payloadBytes, err := json.Marshal(c)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
-	lastID, err := strconv.Atoi(string(bytes))
+	signed := cursorVersion + "." + base64.RawURLEncoding.EncodeToString(payloadBytes)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(p.sign(signed)), nil
+}
+
+func (p *Paginator) decode(encoded string) (cursor, error) {
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 3 || parts[0] != cursorVersion {
+		return cursor{}, fmt.Errorf("%w: unknown format", ErrInvalidCursor)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, p.sign(parts[0]+"."+parts[1])) {
+		return cursor{}, fmt.Errorf("%w: bad signature", ErrInvalidCursor)
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
-		return 0, err
+		return cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var c cursor
+
+	if err = json.Unmarshal(payloadBytes, &c); err != nil {
+		return cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
 	}
 
-	return lastID, nil
+	if c.Direction != DirectionNext && c.Direction != DirectionPrev {
+		return cursor{}, fmt.Errorf("%w: unknown direction", ErrInvalidCursor)
+	}
+
+	return c, nil
+}
+
+func (p *Paginator) sign(data string) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
 }
 
-func EncodeCursor(id int) string {
-	key := fmt.Sprintf("%v", id)
-	return base64.StdEncoding.EncodeToString([]byte(key))
+// Fingerprint identifies filters a cursor is created for.
+func Fingerprint(filter any) (string, error) {
+	filterBytes, err := json.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(filterBytes)
+
+	return hex.EncodeToString(sum[:16]), nil
 }
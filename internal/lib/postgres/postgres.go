@@ -2,15 +2,27 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Verce11o/effective-mobile-test/internal/config"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log"
 )
 
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
+
 func New(ctx context.Context, cfg *config.Config) *pgxpool.Pool {
+	sslMode := "disable"
+	if cfg.Postgres.SSLMode {
+		sslMode = "require"
+	}
+
 	db, err := pgxpool.New(ctx, fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.Name, cfg.Postgres.SSLMode))
+		cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.Name, sslMode))
 
 	if err != nil {
 		log.Fatal("Error connecting to database: ", err)
@@ -22,3 +34,15 @@ func New(ctx context.Context, cfg *config.Config) *pgxpool.Pool {
 
 	return db
 }
+
+// IsUniqueViolation reports whether err is a postgres unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// IsForeignKeyViolation reports whether err is a postgres foreign key constraint violation.
+func IsForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode
+}
@@ -2,17 +2,42 @@ package response
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 	"net/http"
+	"strings"
 )
 
 var (
 	ErrInvalidRequest = errors.New("invalid request")
 	ErrNotFound       = errors.New("not found")
 	ErrGettingCarInfo = errors.New("error getting car info")
+	ErrOwnerExists    = errors.New("owner already exists")
+	ErrOwnerInUse     = errors.New("owner is referenced by cars")
+	ErrConflict       = errors.New("conflict")
+	// ErrPreconditionFailed is returned when a conditional write finds another version of the resource.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrUnavailable is returned when the service is shutting down.
+	ErrUnavailable = errors.New("service unavailable")
+
+	ErrCarInfoNotFound = fmt.Errorf("%w: car not found", ErrGettingCarInfo)
+	ErrInvalidRegNum   = fmt.Errorf("%w: invalid reg num", ErrGettingCarInfo)
 )
 
+// ConflictError is returned when cars with the given reg nums already exist.
+type ConflictError struct {
+	RegNums []string
+}
+
+func (e *ConflictError) Error() string {
+	return "cars already exist: " + strings.Join(e.RegNums, ", ")
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
 func MapHTTPError(err error) (int, string) {
 	switch {
 	case errors.Is(err, ErrInvalidRequest):
@@ -21,8 +46,26 @@ func MapHTTPError(err error) (int, string) {
 		return http.StatusNotFound, "not found"
 	case errors.Is(err, pgx.ErrNoRows):
 		return http.StatusNotFound, "not found"
+	case errors.Is(err, ErrCarInfoNotFound):
+		return http.StatusBadRequest, "car info not found"
+	case errors.Is(err, ErrInvalidRegNum):
+		return http.StatusBadRequest, "invalid reg num"
 	case errors.Is(err, ErrGettingCarInfo):
 		return http.StatusBadRequest, "error getting car info"
+	case errors.Is(err, ErrOwnerExists):
+		return http.StatusConflict, "owner already exists"
+	case errors.Is(err, ErrOwnerInUse):
+		return http.StatusConflict, "owner is referenced by cars"
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, "precondition failed"
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable, "service unavailable"
+	case errors.Is(err, ErrConflict):
+		var conflictErr *ConflictError
+		if errors.As(err, &conflictErr) {
+			return http.StatusConflict, conflictErr.Error()
+		}
+		return http.StatusConflict, "conflict"
 	}
 
 	return http.StatusInternalServerError, "server error"
@@ -31,8 +74,14 @@ func MapHTTPError(err error) (int, string) {
 
 func WithHTTPError(c *gin.Context, err error) {
 	status, message := MapHTTPError(err)
-	c.JSON(status, gin.H{
+	body := gin.H{
 		"message": message,
-	})
-	return
+	}
+
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		body["regNums"] = conflictErr.RegNums
+	}
+
+	c.JSON(status, body)
 }
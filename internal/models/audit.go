@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuditCreate   = "create"
+	AuditUpdate   = "update"
+	AuditDelete   = "delete"
+	AuditRestore  = "restore"
+	AuditTransfer = "transfer"
+	AuditPurge    = "purge"
+)
+
+// FieldChange is the value of a field before and after a change, null when the field had none.
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditEntry is one change of a car. Diff holds changed fields only.
+type AuditEntry struct {
+	ID        int                    `json:"id"`
+	CarID     int                    `json:"car_id"`
+	Actor     string                 `json:"actor,omitempty"`
+	Operation string                 `json:"operation"`
+	Diff      map[string]FieldChange `json:"diff"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type AuditList struct {
+	NextCursor string       `json:"next_cursor,omitempty"`
+	PrevCursor string       `json:"prev_cursor,omitempty"`
+	HasMore    bool         `json:"has_more"`
+	Entries    []AuditEntry `json:"entries"`
+}
+
+const (
+	CarEventCreated = "created"
+	CarEventUpdated = "updated"
+	CarEventDeleted = "deleted"
+)
+
+// ErrInvalidEventPosition is returned for ids of events that were not sent by the change feed.
+var ErrInvalidEventPosition = errors.New("invalid event id")
+
+// EventPosition orders the change feed by the transaction that wrote an entry and then by its id,
+// ids alone are not committed in order.
+type EventPosition struct {
+	Tx int64
+	ID int
+}
+
+func (p EventPosition) Less(other EventPosition) bool {
+	return p.Tx < other.Tx || p.Tx == other.Tx && p.ID < other.ID
+}
+
+// String is the id of the event sent by the change feed.
+func (p EventPosition) String() string {
+	return strconv.FormatInt(p.Tx, 10) + "-" + strconv.Itoa(p.ID)
+}
+
+// ParseEventPosition parses the id of an event sent by the change feed.
+func ParseEventPosition(s string) (EventPosition, error) {
+	tx, id, ok := strings.Cut(s, "-")
+	if !ok {
+		return EventPosition{}, ErrInvalidEventPosition
+	}
+
+	var (
+		position EventPosition
+		err      error
+	)
+
+	if position.Tx, err = strconv.ParseInt(tx, 10, 64); err != nil || position.Tx < 0 {
+		return EventPosition{}, ErrInvalidEventPosition
+	}
+
+	if position.ID, err = strconv.Atoi(id); err != nil || position.ID < 0 {
+		return EventPosition{}, ErrInvalidEventPosition
+	}
+
+	return position, nil
+}
+
+// CarEvent is an audit entry sent to the change feed, Position is the id of the event.
+// Mark and OwnerID are of the car after the change, or before it if the car was removed.
+type CarEvent struct {
+	Type string `json:"type"`
+	AuditEntry
+	Position EventPosition `json:"-"`
+	Mark     *string       `json:"mark,omitempty"`
+	OwnerID  *int          `json:"owner_id,omitempty"`
+	// PreviousMark and PreviousOwnerID are set when the change moved the car away from them.
+	PreviousMark    *string `json:"-"`
+	PreviousOwnerID *int    `json:"-"`
+}
@@ -1,19 +1,103 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Car struct {
 	ID        int       `json:"id" db:"id"`
 	RegNum    string    `json:"regNum" db:"reg_num"`
 	Mark      string    `json:"mark" db:"mark"`
 	Model     string    `json:"model" db:"model"`
-	Year      int       `json:"year" db:"year"`
+	Year      *int      `json:"year" db:"year"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	Owner     People    `json:"owner"`
+	// UpdatedAt is set by every write of the car, it is the Last-Modified of the car.
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Version is incremented by every write, it is the ETag of the car.
+	Version int    `json:"version" db:"version"`
+	Owner   People `json:"owner"`
 }
 
 type CarList struct {
-	Cursor string `json:"cursor"`
-	Total  int    `json:"total"`
-	Cars   []Car  `json:"cars"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	// Total is the number of all cars matching the filters, it is only counted on request.
+	Total          *int `json:"total,omitempty"`
+	TotalEstimated bool `json:"total_estimated,omitempty"`
+	// LastModified is the latest change of any car, lists with deleted cars have none.
+	LastModified *time.Time `json:"last_modified,omitempty"`
+	// Fields lists json fields every car is limited to, all fields are returned when it is empty.
+	Fields []string `json:"fields,omitempty"`
+	Cars   []Car    `json:"cars"`
+}
+
+// MarshalJSON writes only Fields of cars when they are set.
+func (l CarList) MarshalJSON() ([]byte, error) {
+	type carList CarList
+
+	if len(l.Fields) == 0 {
+		return json.Marshal(carList(l))
+	}
+
+	cars := make([]map[string]any, 0, len(l.Cars))
+
+	for _, car := range l.Cars {
+		values := map[string]any{
+			"id":         car.ID,
+			"regNum":     car.RegNum,
+			"mark":       car.Mark,
+			"model":      car.Model,
+			"year":       car.Year,
+			"created_at": car.CreatedAt,
+			"updated_at": car.UpdatedAt,
+			"deleted_at": car.DeletedAt,
+			"version":    car.Version,
+			"owner":      car.Owner,
+		}
+
+		projected := make(map[string]any, len(l.Fields))
+		for _, field := range l.Fields {
+			projected[field] = values[field]
+		}
+
+		cars = append(cars, projected)
+	}
+
+	return json.Marshal(struct {
+		carList
+		Cars []map[string]any `json:"cars"`
+	}{carList: carList(l), Cars: cars})
+}
+
+const (
+	PlateStatusCreated       = "created"
+	PlateStatusNotFound      = "not_found"
+	PlateStatusInvalid       = "invalid"
+	PlateStatusDuplicate     = "duplicate"
+	PlateStatusUpstreamError = "upstream_error"
+)
+
+type PlateResult struct {
+	RegNum string `json:"regNum"`
+	Status string `json:"status"`
+	CarID  int    `json:"carId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type CreateCarsResult struct {
+	Created    []Car         `json:"created"`
+	Existing   []Car         `json:"existing,omitempty"`
+	Skipped    []string      `json:"skipped,omitempty"`
+	CreatedIDs []int         `json:"createdIds"`
+	Results    []PlateResult `json:"results"`
+}
+
+type OwnershipRecord struct {
+	PreviousOwner *People    `json:"previous_owner,omitempty"`
+	Owner         People     `json:"owner"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCarListMarshalJSON(t *testing.T) {
+	list := CarList{
+		HasMore: true,
+		Fields:  []string{"id", "regNum"},
+		Cars:    []Car{{ID: 1, RegNum: "X123XX150", Mark: "Lada", Owner: People{ID: 2}}},
+	}
+
+	data, err := json.Marshal(list)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"has_more":true,"fields":["id","regNum"],"cars":[{"id":1,"regNum":"X123XX150"}]}`, string(data))
+
+	// cached lists are decoded and written again
+	var decoded CarList
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	again, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(again))
+}
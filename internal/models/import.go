@@ -0,0 +1,19 @@
+package models
+
+// ImportResult reports an import of cars, rows are counted without the header. Truncated is
+// set when the import stopped before the end of the file, rows after the last one counted
+// were not read.
+type ImportResult struct {
+	Rows      int              `json:"rows"`
+	Created   int              `json:"created"`
+	Rejected  int              `json:"rejected"`
+	Truncated bool             `json:"truncated"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// ImportRowError is the reason a row was rejected, Row is its line in the file.
+type ImportRowError struct {
+	Row    int    `json:"row"`
+	RegNum string `json:"regNum,omitempty"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+type Job struct {
+	ID        int    `json:"id"`
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Attempts  int    `json:"-"`
+	// Result holds outcomes of processed plates, it grows while the job is running.
+	Result     *CreateCarsResult `json:"result,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	// ChunkEnd is the end of the chunk an interrupted attempt was processing.
+	ChunkEnd int `json:"-"`
+}
@@ -6,3 +6,11 @@ type People struct {
 	Surname    string `json:"surname" db:"surname"`
 	Patronymic string `json:"patronymic,omitempty" db:"patronymic"`
 }
+
+type OwnerList struct {
+	NextCursor string   `json:"next_cursor,omitempty"`
+	PrevCursor string   `json:"prev_cursor,omitempty"`
+	HasMore    bool     `json:"has_more"`
+	Total      int      `json:"total"`
+	Owners     []People `json:"owners"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// CarStats aggregates cars matching list filters.
+type CarStats struct {
+	Total int `json:"total"`
+	// AverageAge is in years, it is nil when none of the cars has a year.
+	AverageAge  *float64      `json:"average_age"`
+	ByMark      []MarkCount   `json:"by_mark"`
+	ByModel     []ModelCount  `json:"by_model"`
+	ByYear      []YearCount   `json:"by_year"`
+	ByDecade    []DecadeCount `json:"by_decade"`
+	TopOwners   []OwnerCount  `json:"top_owners"`
+	AddedPerDay []DayCount    `json:"added_per_day"`
+}
+
+type MarkCount struct {
+	Mark  string `json:"mark"`
+	Count int    `json:"count"`
+}
+
+type ModelCount struct {
+	Mark  string `json:"mark"`
+	Model string `json:"model"`
+	Count int    `json:"count"`
+}
+
+type YearCount struct {
+	Year  int `json:"year"`
+	Count int `json:"count"`
+}
+
+type DecadeCount struct {
+	Decade int `json:"decade"`
+	Count  int `json:"count"`
+}
+
+type OwnerCount struct {
+	Owner People `json:"owner"`
+	Count int    `json:"count"`
+}
+
+type DayCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
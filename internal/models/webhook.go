@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookEventCarCreated = "car.created"
+	WebhookEventCarUpdated = "car.updated"
+	WebhookEventCarDeleted = "car.deleted"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	// DeliveryStatusDead is the final status of deliveries that failed every attempt.
+	DeliveryStatusDead = "dead"
+)
+
+// Webhook is a subscription of a target url to car events. The secret signs deliveries,
+// it is only returned when the webhook is created.
+type Webhook struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookList struct {
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+	Webhooks   []Webhook `json:"webhooks"`
+}
+
+// WebhookEvent is the body of a delivery. Data holds the changed fields of the car with their
+// values before and after the change, as in the car history.
+type WebhookEvent struct {
+	ID        int             `json:"id"`
+	Type      string          `json:"type"`
+	CarID     int             `json:"car_id"`
+	Actor     string          `json:"actor,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// WebhookDelivery is one event sent to one webhook. ResponseStatus and Error are of the last attempt.
+type WebhookDelivery struct {
+	ID             int        `json:"id"`
+	WebhookID      int        `json:"webhook_id"`
+	EventID        int        `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryList struct {
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+	HasMore    bool              `json:"has_more"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// DeliveryTask is a claimed delivery with everything needed to send it.
+type DeliveryTask struct {
+	DeliveryID int
+	Attempts   int
+	URL        string
+	Secret     string
+	Event      WebhookEvent
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/request"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Service
+type Service interface {
+	CreateOwner(ctx context.Context, input domain.CreateOwnerRequest) (models.People, error)
+	GetOwner(ctx context.Context, ownerID int) (models.People, error)
+	GetOwners(ctx context.Context, input domain.GetOwnersRequest) (models.OwnerList, error)
+	GetOwnerCars(ctx context.Context, ownerID int, input domain.GetCarsRequest) (models.CarList, error)
+	UpdateOwner(ctx context.Context, ownerID int, input domain.UpdateOwnerRequest) error
+	DeleteOwner(ctx context.Context, ownerID int) error
+}
+
+type Handler struct {
+	log     *zap.SugaredLogger
+	service Service
+	tracer  trace.Tracer
+}
+
+func NewHandler(log *zap.SugaredLogger, service Service, tracer trace.Tracer) *Handler {
+	return &Handler{log: log, service: service, tracer: tracer}
+}
+
+// CreateOwner godoc
+// @Summary Create owner
+// @Description Create new owner
+// @Tags owners
+// @Accept  json
+// @Produce  json
+// @Param   owner body domain.CreateOwnerRequest true "Create Owner Request"
+// @Success 201 {object} models.People
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /owners [post]
+func (h *Handler) CreateOwner(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ownerHandler.CreateOwner")
+	defer span.End()
+
+	var input domain.CreateOwnerRequest
+
+	if err := request.Read(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err,
+		})
+		return
+	}
+
+	owner, err := h.service.CreateOwner(ctx, input)
+	if err != nil {
+		h.log.Infof("error while creating owner: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, owner)
+}
+
+// GetOwner godoc
+// @Summary Get owner
+// @Description Get owner by id
+// @Tags owners
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Owner ID"
+// @Success 200 {object} models.People
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /owners/{id} [get]
+func (h *Handler) GetOwner(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ownerHandler.GetOwner")
+	defer span.End()
+
+	ownerID, ok := readOwnerID(c)
+	if !ok {
+		return
+	}
+
+	owner, err := h.service.GetOwner(ctx, ownerID)
+	if err != nil {
+		h.log.Infof("error while getting owner %v: %v", ownerID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, owner)
+}
+
+// GetOwners godoc
+// @Summary Get owners
+// @Description Get owners with provided params
+// @Tags owners
+// @Accept  json
+// @Produce  json
+// @Param   name query string false "Owner name"
+// @Param   surname query string false "Owner surname"
+// @Param   cursor query string false "Cursor"
+// @Param   limit query int false "Page size"
+// @Success 200 {object} models.OwnerList
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /owners [get]
+func (h *Handler) GetOwners(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ownerHandler.GetOwners")
+	defer span.End()
+
+	var input domain.GetOwnersRequest
+
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	owners, err := h.service.GetOwners(ctx, input)
+	if err != nil {
+		h.log.Infof("error while getting owners: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, owners)
+}
+
+// GetOwnerCars godoc
+// @Summary Get owner cars
+// @Description Get cars that belong to owner
+// @Tags owners
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Owner ID"
+// @Param   cursor query string false "Cursor"
+// @Param   limit query int false "Page size"
+// @Param   sort query string false "Sort fields, see GET /cars"
+// @Param   fields query string false "Car fields to return, see GET /cars"
+// @Param   embed query string false "owner adds the owner to cars when fields are set"
+// @Success 200 {object} models.CarList
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /owners/{id}/cars [get]
+func (h *Handler) GetOwnerCars(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ownerHandler.GetOwnerCars")
+	defer span.End()
+
+	ownerID, ok := readOwnerID(c)
+	if !ok {
+		return
+	}
+
+	var input domain.GetCarsRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	cars, err := h.service.GetOwnerCars(ctx, ownerID, input)
+	if err != nil {
+		h.log.Infof("error while getting owner %v cars: %v", ownerID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cars)
+}
+
+// UpdateOwner godoc
+// @Summary Update owner
+// @Description Update owner by id
+// @Tags owners
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Owner ID"
+// @Param   owner body domain.UpdateOwnerRequest true "Update Owner Request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /owners/{id} [put]
+func (h *Handler) UpdateOwner(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ownerHandler.UpdateOwner")
+	defer span.End()
+
+	ownerID, ok := readOwnerID(c)
+	if !ok {
+		return
+	}
+
+	var input domain.UpdateOwnerRequest
+	if err := request.Read(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err,
+		})
+		return
+	}
+
+	err := h.service.UpdateOwner(ctx, ownerID, input)
+	if err != nil {
+		h.log.Infof("error while updating owner %v: %v", ownerID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
+
+// DeleteOwner godoc
+// @Summary Delete owner
+// @Description Delete owner by id. Owners referenced by cars or their ownership history cannot be deleted
+// @Tags owners
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Owner ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /owners/{id} [delete]
+func (h *Handler) DeleteOwner(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ownerHandler.DeleteOwner")
+	defer span.End()
+
+	ownerID, ok := readOwnerID(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteOwner(ctx, ownerID)
+	if err != nil {
+		h.log.Infof("error while deleting owner %v: %v", ownerID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
+
+func readOwnerID(c *gin.Context) (int, bool) {
+	ownerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "id must be integer",
+		})
+		return 0, false
+	}
+
+	return ownerID, true
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/Verce11o/effective-mobile-test/internal/owners/handler/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_CreateOwner(t *testing.T) {
+	type args struct {
+		input map[string]any
+	}
+	tests := []struct {
+		name       string
+		args       args
+		statusCode int
+		wantErr    error
+	}{
+		{
+			name: "create owner",
+			args: args{
+				input: map[string]any{
+					"name":    "Ivan",
+					"surname": "Ivanov",
+				},
+			},
+			statusCode: http.StatusCreated,
+		},
+		{
+			name: "missing surname",
+			args: args{
+				input: map[string]any{
+					"name": "Ivan",
+				},
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "owner exists",
+			args: args{
+				input: map[string]any{
+					"name":    "Ivan",
+					"surname": "Ivanov",
+				},
+			},
+			statusCode: http.StatusConflict,
+			wantErr:    response.ErrOwnerExists,
+		},
+	}
+
+	log := logger.NewMockLogger()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+
+		ctx.Request = &http.Request{
+			Method: http.MethodPost,
+			Header: make(http.Header),
+		}
+
+		MockJsonPost(ctx, tt.args.input)
+
+		serviceMock := mocks.NewService(t)
+
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				log:     log,
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			serviceMock.On("CreateOwner", mock.Anything, mock.AnythingOfType("domain.CreateOwnerRequest")).Return(models.People{}, tt.wantErr).Maybe()
+			h.CreateOwner(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_GetOwner(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		statusCode int
+		wantErr    error
+	}{
+		{
+			name:       "get owner",
+			id:         "1",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "invalid id",
+			id:         "abc",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	log := logger.NewMockLogger()
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+
+		ctx.Request = &http.Request{
+			Method: http.MethodGet,
+			Header: make(http.Header),
+		}
+		ctx.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+		serviceMock := mocks.NewService(t)
+
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				log:     log,
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			serviceMock.On("GetOwner", mock.Anything, mock.AnythingOfType("int")).Return(models.People{}, tt.wantErr).Maybe()
+			h.GetOwner(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func MockJsonPost(c *gin.Context, body interface{}) {
+	c.Request.Method = "POST"
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	jsonbytes, err := json.Marshal(body)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/postgres"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ownerColumns are fields owners can be sorted by.
+var ownerColumns = map[string]pagination.Column[models.People]{
+	"id": {
+		Expr:  "id",
+		Value: func(owner models.People) any { return owner.ID },
+		Dst:   func() any { return new(int) },
+	},
+}
+
+type OwnerRepository struct {
+	db     *pgxpool.Pool
+	keyset *pagination.Keyset[models.People]
+	tracer trace.Tracer
+}
+
+func NewOwnerRepository(db *pgxpool.Pool, paginator *pagination.Paginator, tracer trace.Tracer) *OwnerRepository {
+	return &OwnerRepository{
+		db:     db,
+		keyset: pagination.NewKeyset(paginator, ownerColumns, nil, "id"),
+		tracer: tracer,
+	}
+}
+
+func (o *OwnerRepository) CreateOwner(ctx context.Context, input domain.CreateOwnerRequest) (models.People, error) {
+	ctx, span := o.tracer.Start(ctx, "ownerRepository.CreateOwner")
+	defer span.End()
+
+	q := `INSERT INTO owners (name, surname, patronymic) VALUES ($1, $2, $3)
+			RETURNING id, name, surname, COALESCE(patronymic, '')`
+
+	var owner models.People
+
+	err := o.db.QueryRow(ctx, q, input.Name, input.Surname, input.Patronymic).
+		Scan(&owner.ID, &owner.Name, &owner.Surname, &owner.Patronymic)
+
+	if postgres.IsUniqueViolation(err) {
+		return models.People{}, response.ErrOwnerExists
+	}
+
+	if err != nil {
+		return models.People{}, err
+	}
+
+	return owner, nil
+}
+
+func (o *OwnerRepository) GetOwner(ctx context.Context, ownerID int) (models.People, error) {
+	ctx, span := o.tracer.Start(ctx, "ownerRepository.GetOwner")
+	defer span.End()
+
+	q := "SELECT id, name, surname, COALESCE(patronymic, '') FROM owners WHERE id = $1"
+
+	var owner models.People
+
+	err := o.db.QueryRow(ctx, q, ownerID).Scan(&owner.ID, &owner.Name, &owner.Surname, &owner.Patronymic)
+	if err != nil {
+		return models.People{}, err
+	}
+
+	return owner, nil
+}
+
+func (o *OwnerRepository) GetOwners(ctx context.Context, input domain.GetOwnersRequest) (models.OwnerList, error) {
+	ctx, span := o.tracer.Start(ctx, "ownerRepository.GetOwners")
+	defer span.End()
+
+	// a cursor is only valid for the filters it was created with
+	filter := input
+	filter.Cursor, filter.Limit = "", 0
+
+	plan, err := o.keyset.Plan(pagination.Request{
+		Cursor: input.Cursor,
+		Limit:  input.Limit,
+		Filter: filter,
+	})
+	if err != nil {
+		return models.OwnerList{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	query := plan.Apply(sq.Select("id, name, surname, COALESCE(patronymic, '')").From("owners"))
+
+	if input.Name != "" {
+		query = query.Where(sq.Eq{"name": input.Name})
+	}
+
+	if input.Surname != "" {
+		query = query.Where(sq.Eq{"surname": input.Surname})
+	}
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+
+	if err != nil {
+		return models.OwnerList{}, err
+	}
+
+	rows, err := o.db.Query(ctx, sql, args...)
+	if err != nil {
+		return models.OwnerList{}, err
+	}
+
+	defer rows.Close()
+
+	owners := make([]models.People, 0)
+
+	for rows.Next() {
+		var owner models.People
+
+		err = rows.Scan(&owner.ID, &owner.Name, &owner.Surname, &owner.Patronymic)
+		if err != nil {
+			return models.OwnerList{}, err
+		}
+
+		owners = append(owners, owner)
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.OwnerList{}, err
+	}
+
+	owners, page, err := plan.Page(owners)
+	if err != nil {
+		return models.OwnerList{}, err
+	}
+
+	return models.OwnerList{
+		NextCursor: page.Next,
+		PrevCursor: page.Prev,
+		HasMore:    page.HasMore,
+		Owners:     owners,
+		Total:      len(owners),
+	}, nil
+}
+
+func (o *OwnerRepository) GetOwnerCarIDs(ctx context.Context, ownerID int) ([]int, error) {
+	ctx, span := o.tracer.Start(ctx, "ownerRepository.GetOwnerCarIDs")
+	defer span.End()
+
+	rows, err := o.db.Query(ctx, "SELECT id FROM cars WHERE ownerid = $1", ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+func (o *OwnerRepository) UpdateOwner(ctx context.Context, ownerID int, input domain.UpdateOwnerRequest) error {
+	ctx, span := o.tracer.Start(ctx, "ownerRepository.UpdateOwner")
+	defer span.End()
+
+	q := `UPDATE owners SET name = COALESCE(NULLIF($1, ''), name),
+				surname = COALESCE(NULLIF($2, ''), surname),
+				patronymic = COALESCE($3, patronymic) WHERE id = $4`
+
+	tx, err := o.db.Begin(ctx)
+
+	if err != nil {
+		return fmt.Errorf("error begin transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, q, input.Name, input.Surname, input.Patronymic, ownerID)
+
+	if postgres.IsUniqueViolation(err) {
+		return response.ErrOwnerExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	// cars embed their owner, so they are changed together with it
+	_, err = tx.Exec(ctx, "UPDATE cars SET version = version + 1, updated_at = NOW() AT TIME ZONE 'utc' WHERE ownerid = $1", ownerID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (o *OwnerRepository) DeleteOwner(ctx context.Context, ownerID int) error {
+	ctx, span := o.tracer.Start(ctx, "ownerRepository.DeleteOwner")
+	defer span.End()
+
+	tag, err := o.db.Exec(ctx, "DELETE FROM owners WHERE id = $1", ownerID)
+
+	if postgres.IsForeignKeyViolation(err) {
+		return response.ErrOwnerInUse
+	}
+
+	if err != nil {
+		return fmt.Errorf("delete owner: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CarCacheRepository is an autogenerated mock type for the CarCacheRepository type
+type CarCacheRepository struct {
+	mock.Mock
+}
+
+// DeleteCar provides a mock function with given fields: ctx, carID
+func (_m *CarCacheRepository) DeleteCar(ctx context.Context, carID int) error {
+	ret := _m.Called(ctx, carID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCar")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, carID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteCarList provides a mock function with given fields: ctx
+func (_m *CarCacheRepository) DeleteCarList(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCarList")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCarCacheRepository creates a new instance of CarCacheRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCarCacheRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CarCacheRepository {
+	mock := &CarCacheRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,181 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/Verce11o/effective-mobile-test/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/Verce11o/effective-mobile-test/internal/models"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// CreateOwner provides a mock function with given fields: ctx, input
+func (_m *Repository) CreateOwner(ctx context.Context, input domain.CreateOwnerRequest) (models.People, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOwner")
+	}
+
+	var r0 models.People
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateOwnerRequest) (models.People, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateOwnerRequest) models.People); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.People)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateOwnerRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteOwner provides a mock function with given fields: ctx, ownerID
+func (_m *Repository) DeleteOwner(ctx context.Context, ownerID int) error {
+	ret := _m.Called(ctx, ownerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteOwner")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, ownerID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetOwner provides a mock function with given fields: ctx, ownerID
+func (_m *Repository) GetOwner(ctx context.Context, ownerID int) (models.People, error) {
+	ret := _m.Called(ctx, ownerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOwner")
+	}
+
+	var r0 models.People
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.People, error)); ok {
+		return rf(ctx, ownerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.People); ok {
+		r0 = rf(ctx, ownerID)
+	} else {
+		r0 = ret.Get(0).(models.People)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, ownerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOwnerCarIDs provides a mock function with given fields: ctx, ownerID
+func (_m *Repository) GetOwnerCarIDs(ctx context.Context, ownerID int) ([]int, error) {
+	ret := _m.Called(ctx, ownerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOwnerCarIDs")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]int, error)); ok {
+		return rf(ctx, ownerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []int); ok {
+		r0 = rf(ctx, ownerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, ownerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOwners provides a mock function with given fields: ctx, input
+func (_m *Repository) GetOwners(ctx context.Context, input domain.GetOwnersRequest) (models.OwnerList, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOwners")
+	}
+
+	var r0 models.OwnerList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetOwnersRequest) (models.OwnerList, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetOwnersRequest) models.OwnerList); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.OwnerList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.GetOwnersRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateOwner provides a mock function with given fields: ctx, ownerID, input
+func (_m *Repository) UpdateOwner(ctx context.Context, ownerID int, input domain.UpdateOwnerRequest) error {
+	ret := _m.Called(ctx, ownerID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOwner")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateOwnerRequest) error); ok {
+		r0 = rf(ctx, ownerID, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
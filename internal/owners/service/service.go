@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Repository
+type Repository interface {
+	CreateOwner(ctx context.Context, input domain.CreateOwnerRequest) (models.People, error)
+	GetOwner(ctx context.Context, ownerID int) (models.People, error)
+	GetOwners(ctx context.Context, input domain.GetOwnersRequest) (models.OwnerList, error)
+	GetOwnerCarIDs(ctx context.Context, ownerID int) ([]int, error)
+	UpdateOwner(ctx context.Context, ownerID int, input domain.UpdateOwnerRequest) error
+	DeleteOwner(ctx context.Context, ownerID int) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=CarService
+type CarService interface {
+	GetCars(ctx context.Context, input domain.GetCarsRequest) (models.CarList, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=CarCacheRepository
+type CarCacheRepository interface {
+	DeleteCarList(ctx context.Context) error
+	DeleteCar(ctx context.Context, carID int) error
+}
+
+type Service struct {
+	log      *zap.SugaredLogger
+	repo     Repository
+	cars     CarService
+	carCache CarCacheRepository
+	tracer   trace.Tracer
+}
+
+func NewService(log *zap.SugaredLogger, repo Repository, cars CarService, carCache CarCacheRepository, tracer trace.Tracer) *Service {
+	return &Service{log: log, repo: repo, cars: cars, carCache: carCache, tracer: tracer}
+}
+
+func (s *Service) CreateOwner(ctx context.Context, input domain.CreateOwnerRequest) (models.People, error) {
+	ctx, span := s.tracer.Start(ctx, "ownerService.CreateOwner")
+	defer span.End()
+
+	owner, err := s.repo.CreateOwner(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot create owner: %v", err)
+		return models.People{}, fmt.Errorf("create owner: %w", err)
+	}
+
+	return owner, nil
+}
+
+func (s *Service) GetOwner(ctx context.Context, ownerID int) (models.People, error) {
+	ctx, span := s.tracer.Start(ctx, "ownerService.GetOwner")
+	defer span.End()
+
+	owner, err := s.repo.GetOwner(ctx, ownerID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get owner: %v", err)
+		return models.People{}, fmt.Errorf("get owner: %w", err)
+	}
+
+	return owner, nil
+}
+
+func (s *Service) GetOwners(ctx context.Context, input domain.GetOwnersRequest) (models.OwnerList, error) {
+	ctx, span := s.tracer.Start(ctx, "ownerService.GetOwners")
+	defer span.End()
+
+	owners, err := s.repo.GetOwners(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get owners: %v", err)
+		return models.OwnerList{}, fmt.Errorf("get owners: %w", err)
+	}
+
+	return owners, nil
+}
+
+func (s *Service) GetOwnerCars(ctx context.Context, ownerID int, input domain.GetCarsRequest) (models.CarList, error) {
+	ctx, span := s.tracer.Start(ctx, "ownerService.GetOwnerCars")
+	defer span.End()
+
+	if _, err := s.repo.GetOwner(ctx, ownerID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get owner: %v", err)
+		return models.CarList{}, fmt.Errorf("get owner: %w", err)
+	}
+
+	input.OwnerID = ownerID
+
+	cars, err := s.cars.GetCars(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get owner cars: %v", err)
+		return models.CarList{}, fmt.Errorf("get owner cars: %w", err)
+	}
+
+	return cars, nil
+}
+
+func (s *Service) UpdateOwner(ctx context.Context, ownerID int, input domain.UpdateOwnerRequest) error {
+	ctx, span := s.tracer.Start(ctx, "ownerService.UpdateOwner")
+	defer span.End()
+
+	err := s.repo.UpdateOwner(ctx, ownerID, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot update owner: %v", err)
+		return fmt.Errorf("update owner: %w", err)
+	}
+
+	// cached cars embed owner data
+	if err = s.carCache.DeleteCarList(ctx); err != nil {
+		s.log.Infof("cannot clear cache: %v", err)
+	}
+
+	carIDs, err := s.repo.GetOwnerCarIDs(ctx, ownerID)
+	if err != nil {
+		s.log.Infof("cannot get owner %v cars: %v", ownerID, err)
+		return nil
+	}
+
+	for _, carID := range carIDs {
+		if err = s.carCache.DeleteCar(ctx, carID); err != nil {
+			s.log.Infof("cannot delete car %v from cache: %v", carID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteOwner(ctx context.Context, ownerID int) error {
+	ctx, span := s.tracer.Start(ctx, "ownerService.DeleteOwner")
+	defer span.End()
+
+	err := s.repo.DeleteOwner(ctx, ownerID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot delete owner: %v", err)
+		return fmt.Errorf("delete owner: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	repoMock "github.com/Verce11o/effective-mobile-test/internal/owners/service/mocks"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_CreateOwner(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		input domain.CreateOwnerRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				input: domain.CreateOwnerRequest{
+					Name:    "Ivan",
+					Surname: "Ivanov",
+				},
+			},
+		},
+		{
+			name: "owner exists",
+			args: args{
+				ctx: context.Background(),
+				input: domain.CreateOwnerRequest{
+					Name:    "Ivan",
+					Surname: "Ivanov",
+				},
+			},
+			wantErr: response.ErrOwnerExists,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repoMock.NewRepository(t)
+			cars := repoMock.NewCarService(t)
+			carCache := repoMock.NewCarCacheRepository(t)
+
+			repo.On("CreateOwner", mock.Anything, tt.args.input).Return(models.People{ID: 1}, tt.wantErr).Once()
+
+			s := &Service{
+				log:      log,
+				repo:     repo,
+				cars:     cars,
+				carCache: carCache,
+				tracer:   tracer.InitTracer(tt.args.ctx, "", ""),
+			}
+
+			_, err := s.CreateOwner(tt.args.ctx, tt.args.input)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_GetOwnerCars(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		ownerID int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		ownerErr error
+		wantErr  error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				ownerID: 1,
+			},
+		},
+		{
+			name: "owner not found",
+			args: args{
+				ctx:     context.Background(),
+				ownerID: 2,
+			},
+			ownerErr: pgx.ErrNoRows,
+			wantErr:  pgx.ErrNoRows,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repoMock.NewRepository(t)
+			cars := repoMock.NewCarService(t)
+			carCache := repoMock.NewCarCacheRepository(t)
+
+			repo.On("GetOwner", mock.Anything, tt.args.ownerID).Return(models.People{ID: tt.args.ownerID}, tt.ownerErr).Once()
+			cars.On("GetCars", mock.Anything, domain.GetCarsRequest{OwnerID: tt.args.ownerID}).Maybe().Return(models.CarList{}, nil)
+
+			s := &Service{
+				log:      log,
+				repo:     repo,
+				cars:     cars,
+				carCache: carCache,
+				tracer:   tracer.InitTracer(tt.args.ctx, "", ""),
+			}
+
+			_, err := s.GetOwnerCars(tt.args.ctx, tt.args.ownerID, domain.GetCarsRequest{})
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_UpdateOwner(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		ownerID int
+		input   domain.UpdateOwnerRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				ownerID: 1,
+				input:   domain.UpdateOwnerRequest{Surname: "Petrov"},
+			},
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repoMock.NewRepository(t)
+			cars := repoMock.NewCarService(t)
+			carCache := repoMock.NewCarCacheRepository(t)
+
+			repo.On("UpdateOwner", mock.Anything, tt.args.ownerID, tt.args.input).Return(nil).Once()
+			carCache.On("DeleteCarList", mock.Anything).Return(nil).Once()
+			repo.On("GetOwnerCarIDs", mock.Anything, tt.args.ownerID).Return([]int{1, 2}, nil).Once()
+			carCache.On("DeleteCar", mock.Anything, mock.AnythingOfType("int")).Return(nil).Twice()
+
+			s := &Service{
+				log:      log,
+				repo:     repo,
+				cars:     cars,
+				carCache: carCache,
+				tracer:   tracer.InitTracer(tt.args.ctx, "", ""),
+			}
+
+			err := s.UpdateOwner(tt.args.ctx, tt.args.ownerID, tt.args.input)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_DeleteOwner(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		ownerID int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				ownerID: 1,
+			},
+		},
+		{
+			name: "owner has cars",
+			args: args{
+				ctx:     context.Background(),
+				ownerID: 1,
+			},
+			wantErr: response.ErrOwnerInUse,
+		},
+	}
+
+	log := logger.NewMockLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repoMock.NewRepository(t)
+			cars := repoMock.NewCarService(t)
+			carCache := repoMock.NewCarCacheRepository(t)
+
+			repo.On("DeleteOwner", mock.Anything, tt.args.ownerID).Return(tt.wantErr).Once()
+
+			s := &Service{
+				log:      log,
+				repo:     repo,
+				cars:     cars,
+				carCache: carCache,
+				tracer:   tracer.InitTracer(tt.args.ctx, "", ""),
+			}
+
+			err := s.DeleteOwner(tt.args.ctx, tt.args.ownerID)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
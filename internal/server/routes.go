@@ -9,8 +9,19 @@ import (
 	"github.com/Verce11o/effective-mobile-test/internal/cars/repository"
 	"github.com/Verce11o/effective-mobile-test/internal/cars/service"
 	"github.com/Verce11o/effective-mobile-test/internal/config"
+	jobhandler "github.com/Verce11o/effective-mobile-test/internal/jobs/handler"
+	jobrepository "github.com/Verce11o/effective-mobile-test/internal/jobs/repository"
+	jobservice "github.com/Verce11o/effective-mobile-test/internal/jobs/service"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/actor"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/communicator"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
 	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	ownerhandler "github.com/Verce11o/effective-mobile-test/internal/owners/handler"
+	ownerrepository "github.com/Verce11o/effective-mobile-test/internal/owners/repository"
+	ownerservice "github.com/Verce11o/effective-mobile-test/internal/owners/service"
+	webhookhandler "github.com/Verce11o/effective-mobile-test/internal/webhooks/handler"
+	webhookrepository "github.com/Verce11o/effective-mobile-test/internal/webhooks/repository"
+	webhookservice "github.com/Verce11o/effective-mobile-test/internal/webhooks/service"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -19,6 +30,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/zap"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -29,6 +41,9 @@ type Server struct {
 	tracer     *tracer.JaegerTracing
 	cfg        *config.Config
 	httpServer *http.Server
+	jobs       *jobservice.Service
+	cars       *service.Service
+	webhooks   *webhookservice.Service
 }
 
 func NewServer(log *zap.SugaredLogger, db *pgxpool.Pool, redis *redis.Client, cfg *config.Config, tracer *tracer.JaegerTracing) *Server {
@@ -43,6 +58,8 @@ func (s *Server) Run(handler http.Handler) error {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
+	// event streams never end by themselves, so they are closed for shutdown to complete
+	s.httpServer.RegisterOnShutdown(s.cars.StopCarEvents)
 	s.log.Infof("Server running on: %v", addr)
 	return s.httpServer.ListenAndServe()
 }
@@ -54,31 +71,136 @@ func (s *Server) InitRoutes() *gin.Engine {
 
 	router.Use(cors.New(cors.Config{
 		AllowAllOrigins: true,
-		AllowMethods:    []string{"GET", "POST", "PUT", "DELETE"},
+		AllowMethods:    []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:    []string{"Origin", "Content-Length", "Content-Type", "If-Match", "If-None-Match", "If-Modified-Since", "Last-Event-ID", actor.Header},
+		ExposeHeaders:   []string{"ETag", "Last-Modified"},
 	}))
 
+	router.Use(actor.Middleware())
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	carRepo := repository.NewCarRepository(s.db, s.tracer.Tracer)
+	if s.cfg.Pagination.CursorSecret == "" {
+		s.log.Warnf("pagination cursor secret is not set, cursors will not survive restart")
+	}
+
+	paginator := pagination.NewPaginator(s.cfg.Pagination.CursorSecret, s.cfg.Pagination.DefaultLimit, s.cfg.Pagination.MaxLimit)
+
+	carRepo := repository.NewCarRepository(s.db, paginator, s.tracer.Tracer)
 	carCache := repository.NewCarCacheRepository(s.redis, s.tracer.Tracer)
 
-	carCommunicator := communicator.NewCommunicator(s.cfg.ExternalCarsApi.URL)
-	carService := service.NewService(s.log, carRepo, carCache, s.tracer.Tracer, carCommunicator)
-	carHandler := handler.NewHandler(s.log, carService, s.tracer.Tracer)
+	carCommunicator := communicator.NewCachedCommunicator(
+		communicator.NewCommunicator(s.log, s.tracer.Tracer, s.cfg.ExternalCarsApi),
+		s.redis, s.log, s.tracer.Tracer,
+		s.cfg.ExternalCarsApi.Cache.TTL, s.cfg.ExternalCarsApi.Cache.NotFoundTTL, s.cfg.ExternalCarsApi.EnrichTimeout,
+	)
+	webhookRepo := webhookrepository.NewWebhookRepository(s.db, paginator, s.tracer.Tracer)
+	s.webhooks = webhookservice.NewService(s.log, webhookRepo, s.tracer.Tracer, webhookservice.Options{
+		Workers:        s.cfg.Webhooks.Workers,
+		PollInterval:   s.cfg.Webhooks.PollInterval,
+		Timeout:        s.cfg.Webhooks.Timeout,
+		Lease:          s.cfg.Webhooks.Lease,
+		MaxAttempts:    s.cfg.Webhooks.MaxAttempts,
+		RetryBaseDelay: s.cfg.Webhooks.RetryBaseDelay,
+		RetryMaxDelay:  s.cfg.Webhooks.RetryMaxDelay,
+	})
+	webhookHandler := webhookhandler.NewHandler(s.log, s.webhooks, s.tracer.Tracer)
+
+	carService := service.NewService(s.log, carRepo, carCache, s.tracer.Tracer, carCommunicator, carCommunicator, service.EnrichOptions{
+		Concurrency: s.cfg.ExternalCarsApi.Concurrency,
+		Timeout:     s.cfg.ExternalCarsApi.EnrichTimeout,
+	}, s.cfg.Cars.DeletedRetention)
+	s.cars = carService
+
+	jobRepo := jobrepository.NewJobRepository(s.db, s.tracer.Tracer)
+	s.jobs = jobservice.NewService(s.log, jobRepo, carService, s.tracer.Tracer, jobservice.Options{
+		Workers:      s.cfg.Jobs.Workers,
+		PollInterval: s.cfg.Jobs.PollInterval,
+		ChunkSize:    s.cfg.Jobs.ChunkSize,
+		Lease:        s.cfg.Jobs.Lease,
+		MaxAttempts:  s.cfg.Jobs.MaxAttempts,
+	})
+	jobHandler := jobhandler.NewHandler(s.log, s.jobs, s.tracer.Tracer)
+
+	carHandler := handler.NewHandler(s.log, carService, s.jobs, s.tracer.Tracer)
+
+	ownerRepo := ownerrepository.NewOwnerRepository(s.db, paginator, s.tracer.Tracer)
+	ownerService := ownerservice.NewService(s.log, ownerRepo, carService, carCache, s.tracer.Tracer)
+	ownerHandler := ownerhandler.NewHandler(s.log, ownerService, s.tracer.Tracer)
 
 	api := router.Group("/api/v1")
 
 	cars := api.Group("/cars")
 	{
 		cars.POST("", carHandler.CreateCar)
-		cars.GET("", carHandler.GetCars)
+		cars.POST("/import", carHandler.ImportCars)
+		cars.GET("", handler.CacheControl(s.cfg.HTTPCache.CarList), carHandler.GetCars)
+		cars.GET("/search", carHandler.SearchCars)
+		cars.GET("/stats", carHandler.GetCarStats)
+		cars.GET("/export", carHandler.ExportCars)
+		cars.GET("/events", carHandler.StreamCarEvents)
 		cars.PUT("", carHandler.UpdateCar)
+		cars.PUT("/:id", carHandler.UpdateCar)
+		cars.PATCH("/:id", carHandler.PatchCar)
 		cars.DELETE("", carHandler.DeleteCar)
+		cars.DELETE("/:id", carHandler.DeleteCar)
+		cars.GET("/:id", handler.CacheControl(s.cfg.HTTPCache.Car), carHandler.GetCar)
+		cars.GET("/by-regnum/:regNum", handler.CacheControl(s.cfg.HTTPCache.Car), carHandler.GetCarByRegNum)
+		cars.DELETE("/info-cache/:regNum", carHandler.EvictCarInfo)
+		cars.POST("/:id/transfer", carHandler.TransferCar)
+		cars.GET("/:id/owners", carHandler.GetCarOwners)
+		cars.GET("/:id/history", carHandler.GetCarHistory)
+		cars.POST("/:id/restore", carHandler.RestoreCar)
+		cars.POST("/purge", carHandler.PurgeCars)
+	}
+
+	owners := api.Group("/owners")
+	{
+		owners.POST("", ownerHandler.CreateOwner)
+		owners.GET("", ownerHandler.GetOwners)
+		owners.GET("/:id", ownerHandler.GetOwner)
+		owners.GET("/:id/cars", ownerHandler.GetOwnerCars)
+		owners.PUT("/:id", ownerHandler.UpdateOwner)
+		owners.DELETE("/:id", ownerHandler.DeleteOwner)
+	}
+
+	jobs := api.Group("/jobs")
+	{
+		jobs.GET("/:id", jobHandler.GetJob)
+	}
+
+	webhooks := api.Group("/webhooks")
+	{
+		webhooks.POST("", webhookHandler.CreateWebhook)
+		webhooks.GET("", webhookHandler.GetWebhooks)
+		webhooks.GET("/:id", webhookHandler.GetWebhook)
+		webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+		webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
 	}
 
 	return router
 }
 
+// RunWorkers processes queued jobs, publishes car events and sends webhooks until ctx is cancelled,
+// InitRoutes must be called first.
+func (s *Server) RunWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.cars.RunCarEvents(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		s.webhooks.Run(ctx)
+	}()
+
+	s.jobs.Run(ctx)
+	wg.Wait()
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"context"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/request"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Service
+type Service interface {
+	CreateWebhook(ctx context.Context, input domain.CreateWebhookRequest) (models.Webhook, error)
+	GetWebhook(ctx context.Context, webhookID int) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, input domain.GetWebhooksRequest) (models.WebhookList, error)
+	UpdateWebhook(ctx context.Context, webhookID int, input domain.UpdateWebhookRequest) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID int) error
+	GetDeliveries(ctx context.Context, webhookID int, input domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error)
+}
+
+type Handler struct {
+	log     *zap.SugaredLogger
+	service Service
+	tracer  trace.Tracer
+}
+
+func NewHandler(log *zap.SugaredLogger, service Service, tracer trace.Tracer) *Handler {
+	return &Handler{log: log, service: service, tracer: tracer}
+}
+
+// CreateWebhook godoc
+// @Summary Create webhook
+// @Description Subscribe a url to car.created, car.updated and car.deleted events. Deliveries are POSTed as json with
+// @Description X-Webhook-Event, X-Webhook-Delivery and X-Webhook-Signature "t=<unix time>,v1=<hex hmac-sha256 of "<unix time>.<body>">"
+// @Description headers and retried with backoff until a 2xx response or the last attempt. The secret is only returned here,
+// @Description a random one is generated when none is given
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   webhook body domain.CreateWebhookRequest true "Create Webhook Request"
+// @Success 201 {object} models.Webhook
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "webhookHandler.CreateWebhook")
+	defer span.End()
+
+	var input domain.CreateWebhookRequest
+
+	if err := request.Read(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err,
+		})
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(ctx, input)
+	if err != nil {
+		h.log.Infof("error while creating webhook: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhook godoc
+// @Summary Get webhook
+// @Description Get webhook by id
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id} [get]
+func (h *Handler) GetWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "webhookHandler.GetWebhook")
+	defer span.End()
+
+	webhookID, ok := readWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.GetWebhook(ctx, webhookID)
+	if err != nil {
+		h.log.Infof("error while getting webhook %v: %v", webhookID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// GetWebhooks godoc
+// @Summary Get webhooks
+// @Description Get webhooks
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   cursor query string false "Cursor"
+// @Param   limit query int false "Page size"
+// @Success 200 {object} models.WebhookList
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [get]
+func (h *Handler) GetWebhooks(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "webhookHandler.GetWebhooks")
+	defer span.End()
+
+	var input domain.GetWebhooksRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	webhooks, err := h.service.GetWebhooks(ctx, input)
+	if err != nil {
+		h.log.Infof("error while getting webhooks: %v", err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// UpdateWebhook godoc
+// @Summary Update webhook
+// @Description Replace url and events of the webhook. Active state is kept when omitted, the secret is rotated when given.
+// @Description Pending deliveries of inactive webhooks are sent once they are active again
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Webhook ID"
+// @Param   webhook body domain.UpdateWebhookRequest true "Update Webhook Request"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id} [put]
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "webhookHandler.UpdateWebhook")
+	defer span.End()
+
+	webhookID, ok := readWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var input domain.UpdateWebhookRequest
+
+	if err := request.Read(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err,
+		})
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(ctx, webhookID, input)
+	if err != nil {
+		h.log.Infof("error while updating webhook %v: %v", webhookID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete webhook
+// @Description Delete webhook by id together with its delivery log
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "webhookHandler.DeleteWebhook")
+	defer span.End()
+
+	webhookID, ok := readWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(ctx, webhookID); err != nil {
+		h.log.Infof("error while deleting webhook %v: %v", webhookID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "success",
+	})
+}
+
+// GetDeliveries godoc
+// @Summary Get webhook deliveries
+// @Description Get the delivery log of the webhook, latest first. Response status and error are of the last attempt,
+// @Description dead deliveries failed every attempt and are not retried
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   id path int true "Webhook ID"
+// @Param   status query string false "pending, delivered or dead"
+// @Param   cursor query string false "Cursor"
+// @Param   limit query int false "Page size"
+// @Success 200 {object} models.WebhookDeliveryList
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "webhookHandler.GetDeliveries")
+	defer span.End()
+
+	webhookID, ok := readWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var input domain.GetWebhookDeliveriesRequest
+
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request",
+		})
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(ctx, webhookID, input)
+	if err != nil {
+		h.log.Infof("error while getting webhook %v deliveries: %v", webhookID, err)
+		response.WithHTTPError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func readWebhookID(c *gin.Context) (int, bool) {
+	webhookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "id must be integer",
+		})
+		return 0, false
+	}
+
+	return webhookID, true
+}
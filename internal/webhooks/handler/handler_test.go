@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/Verce11o/effective-mobile-test/internal/webhooks/handler/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_CreateWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      map[string]any
+		statusCode int
+		wantErr    error
+	}{
+		{
+			name: "create webhook",
+			input: map[string]any{
+				"url":    "https://billing.example.com/hooks",
+				"events": []string{"car.created", "car.deleted"},
+			},
+			statusCode: http.StatusCreated,
+		},
+		{
+			name: "unknown event",
+			input: map[string]any{
+				"url":    "https://billing.example.com/hooks",
+				"events": []string{"car.sold"},
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "no events",
+			input: map[string]any{
+				"url":    "https://billing.example.com/hooks",
+				"events": []string{},
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "short secret",
+			input: map[string]any{
+				"url":    "https://billing.example.com/hooks",
+				"events": []string{"car.created"},
+				"secret": "short",
+			},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "rejected url",
+			input: map[string]any{
+				"url":    "ftp://billing.example.com/hooks",
+				"events": []string{"car.created"},
+			},
+			statusCode: http.StatusBadRequest,
+			wantErr:    response.ErrInvalidRequest,
+		},
+	}
+
+	log := logger.NewMockLogger()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = &http.Request{
+				Method: http.MethodPost,
+				Header: make(http.Header),
+			}
+
+			MockJsonPost(ctx, tt.input)
+
+			serviceMock := mocks.NewService(t)
+
+			h := &Handler{
+				log:     log,
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			serviceMock.On("CreateWebhook", mock.Anything, mock.AnythingOfType("domain.CreateWebhookRequest")).
+				Return(models.Webhook{ID: 1, Secret: "whsec_1"}, tt.wantErr).Maybe()
+			h.CreateWebhook(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func TestHandler_GetDeliveries(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		query      string
+		wantInput  domain.GetWebhookDeliveriesRequest
+		statusCode int
+		wantErr    error
+	}{
+		{
+			name:       "dead deliveries",
+			id:         "1",
+			query:      "?status=dead&limit=5",
+			wantInput:  domain.GetWebhookDeliveriesRequest{Status: models.DeliveryStatusDead, Limit: 5},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "unknown status",
+			id:         "1",
+			query:      "?status=lost",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid id",
+			id:         "abc",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "webhook not found",
+			id:         "2",
+			statusCode: http.StatusNotFound,
+			wantErr:    pgx.ErrNoRows,
+		},
+	}
+
+	log := logger.NewMockLogger()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/"+tt.id+"/deliveries"+tt.query, nil)
+			ctx.Params = gin.Params{{Key: "id", Value: tt.id}}
+
+			serviceMock := mocks.NewService(t)
+
+			h := &Handler{
+				log:     log,
+				service: serviceMock,
+				tracer:  tracer.InitTracer(ctx.Request.Context(), "", ""),
+			}
+
+			if tt.statusCode != http.StatusBadRequest {
+				serviceMock.On("GetDeliveries", mock.Anything, mock.AnythingOfType("int"), tt.wantInput).
+					Return(models.WebhookDeliveryList{Deliveries: []models.WebhookDelivery{}}, tt.wantErr).Once()
+			}
+
+			h.GetDeliveries(ctx)
+
+			assert.EqualValues(t, tt.statusCode, w.Code)
+		})
+	}
+}
+
+func MockJsonPost(c *gin.Context, body interface{}) {
+	c.Request.Method = "POST"
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	jsonbytes, err := json.Marshal(body)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(jsonbytes))
+}
@@ -0,0 +1,190 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/Verce11o/effective-mobile-test/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/Verce11o/effective-mobile-test/internal/models"
+)
+
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, input
+func (_m *Service) CreateWebhook(ctx context.Context, input domain.CreateWebhookRequest) (models.Webhook, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 models.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateWebhookRequest) (models.Webhook, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateWebhookRequest) models.Webhook); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateWebhookRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWebhook provides a mock function with given fields: ctx, webhookID
+func (_m *Service) DeleteWebhook(ctx context.Context, webhookID int) error {
+	ret := _m.Called(ctx, webhookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetDeliveries provides a mock function with given fields: ctx, webhookID, input
+func (_m *Service) GetDeliveries(ctx context.Context, webhookID int, input domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error) {
+	ret := _m.Called(ctx, webhookID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeliveries")
+	}
+
+	var r0 models.WebhookDeliveryList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error)); ok {
+		return rf(ctx, webhookID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetWebhookDeliveriesRequest) models.WebhookDeliveryList); ok {
+		r0 = rf(ctx, webhookID, input)
+	} else {
+		r0 = ret.Get(0).(models.WebhookDeliveryList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.GetWebhookDeliveriesRequest) error); ok {
+		r1 = rf(ctx, webhookID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhook provides a mock function with given fields: ctx, webhookID
+func (_m *Service) GetWebhook(ctx context.Context, webhookID int) (models.Webhook, error) {
+	ret := _m.Called(ctx, webhookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhook")
+	}
+
+	var r0 models.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Webhook, error)); ok {
+		return rf(ctx, webhookID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Webhook); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, webhookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhooks provides a mock function with given fields: ctx, input
+func (_m *Service) GetWebhooks(ctx context.Context, input domain.GetWebhooksRequest) (models.WebhookList, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhooks")
+	}
+
+	var r0 models.WebhookList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetWebhooksRequest) (models.WebhookList, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetWebhooksRequest) models.WebhookList); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.WebhookList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.GetWebhooksRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateWebhook provides a mock function with given fields: ctx, webhookID, input
+func (_m *Service) UpdateWebhook(ctx context.Context, webhookID int, input domain.UpdateWebhookRequest) (models.Webhook, error) {
+	ret := _m.Called(ctx, webhookID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebhook")
+	}
+
+	var r0 models.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateWebhookRequest) (models.Webhook, error)); ok {
+		return rf(ctx, webhookID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateWebhookRequest) models.Webhook); ok {
+		r0 = rf(ctx, webhookID, input)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.UpdateWebhookRequest) error); ok {
+		r1 = rf(ctx, webhookID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Service {
+	mock := &Service{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,524 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/pagination"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"time"
+)
+
+// webhookColumns are fields webhooks can be sorted by.
+var webhookColumns = map[string]pagination.Column[models.Webhook]{
+	"id": {
+		Expr:  "id",
+		Value: func(webhook models.Webhook) any { return webhook.ID },
+		Dst:   func() any { return new(int) },
+	},
+}
+
+// deliveryColumns are fields deliveries can be sorted by.
+var deliveryColumns = map[string]pagination.Column[models.WebhookDelivery]{
+	"id": {
+		Expr:  "d.id",
+		Value: func(delivery models.WebhookDelivery) any { return delivery.ID },
+		Dst:   func() any { return new(int) },
+	},
+}
+
+// deliveryDefaultSort lists the latest deliveries first.
+var deliveryDefaultSort = []pagination.SortKey{{Field: "id", Desc: true}}
+
+// carEventsChannel is notified by the car_audit trigger on every recorded change.
+const carEventsChannel = "car_events"
+
+const webhookFields = "id, url, events, active, created_at, updated_at"
+
+// carEventTypes maps operations of the car audit to events, purges follow deletes and are not sent.
+var carEventTypes = map[string]string{
+	models.AuditCreate:   models.WebhookEventCarCreated,
+	models.AuditUpdate:   models.WebhookEventCarUpdated,
+	models.AuditTransfer: models.WebhookEventCarUpdated,
+	models.AuditRestore:  models.WebhookEventCarUpdated,
+	models.AuditDelete:   models.WebhookEventCarDeleted,
+}
+
+type WebhookRepository struct {
+	db             *pgxpool.Pool
+	keyset         *pagination.Keyset[models.Webhook]
+	deliveryKeyset *pagination.Keyset[models.WebhookDelivery]
+	tracer         trace.Tracer
+}
+
+func NewWebhookRepository(db *pgxpool.Pool, paginator *pagination.Paginator, tracer trace.Tracer) *WebhookRepository {
+	return &WebhookRepository{
+		db:             db,
+		keyset:         pagination.NewKeyset(paginator, webhookColumns, nil, "id"),
+		deliveryKeyset: pagination.NewKeyset(paginator, deliveryColumns, deliveryDefaultSort, "id"),
+		tracer:         tracer,
+	}
+}
+
+func scanWebhook(row pgx.Row, webhook *models.Webhook) error {
+	return row.Scan(&webhook.ID, &webhook.URL, &webhook.Events, &webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+}
+
+// CreateWebhook stores input, the secret must be set already.
+func (w *WebhookRepository) CreateWebhook(ctx context.Context, input domain.CreateWebhookRequest) (models.Webhook, error) {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.CreateWebhook")
+	defer span.End()
+
+	q := `INSERT INTO webhooks (url, events, secret, active) VALUES ($1, $2, $3, COALESCE($4, TRUE))
+			RETURNING ` + webhookFields
+
+	var webhook models.Webhook
+
+	if err := scanWebhook(w.db.QueryRow(ctx, q, input.URL, input.Events, input.Secret, input.Active), &webhook); err != nil {
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (w *WebhookRepository) GetWebhook(ctx context.Context, webhookID int) (models.Webhook, error) {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.GetWebhook")
+	defer span.End()
+
+	var webhook models.Webhook
+
+	err := scanWebhook(w.db.QueryRow(ctx, "SELECT "+webhookFields+" FROM webhooks WHERE id = $1", webhookID), &webhook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (w *WebhookRepository) GetWebhooks(ctx context.Context, input domain.GetWebhooksRequest) (models.WebhookList, error) {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.GetWebhooks")
+	defer span.End()
+
+	plan, err := w.keyset.Plan(pagination.Request{
+		Cursor: input.Cursor,
+		Limit:  input.Limit,
+	})
+	if err != nil {
+		return models.WebhookList{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	sql, args, err := plan.Apply(sq.Select(webhookFields).From("webhooks")).PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return models.WebhookList{}, err
+	}
+
+	rows, err := w.db.Query(ctx, sql, args...)
+	if err != nil {
+		return models.WebhookList{}, err
+	}
+
+	defer rows.Close()
+
+	webhooks := make([]models.Webhook, 0)
+
+	for rows.Next() {
+		var webhook models.Webhook
+
+		if err = scanWebhook(rows, &webhook); err != nil {
+			return models.WebhookList{}, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.WebhookList{}, err
+	}
+
+	webhooks, page, err := plan.Page(webhooks)
+	if err != nil {
+		return models.WebhookList{}, err
+	}
+
+	return models.WebhookList{
+		NextCursor: page.Next,
+		PrevCursor: page.Prev,
+		HasMore:    page.HasMore,
+		Webhooks:   webhooks,
+	}, nil
+}
+
+// UpdateWebhook keeps the active state when input has none and the secret when it is empty.
+func (w *WebhookRepository) UpdateWebhook(ctx context.Context, webhookID int, input domain.UpdateWebhookRequest) (models.Webhook, error) {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.UpdateWebhook")
+	defer span.End()
+
+	q := `UPDATE webhooks
+			SET url = $1, events = $2, active = COALESCE($3, active), secret = COALESCE(NULLIF($4, ''), secret),
+				updated_at = NOW() AT TIME ZONE 'utc'
+			WHERE id = $5
+			RETURNING ` + webhookFields
+
+	var webhook models.Webhook
+
+	err := scanWebhook(w.db.QueryRow(ctx, q, input.URL, input.Events, input.Active, input.Secret, webhookID), &webhook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook removes the webhook with its deliveries.
+func (w *WebhookRepository) DeleteWebhook(ctx context.Context, webhookID int) error {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.DeleteWebhook")
+	defer span.End()
+
+	tag, err := w.db.Exec(ctx, "DELETE FROM webhooks WHERE id = $1", webhookID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListenCarEvents calls fn, then again after every notification of a car change, or after poll
+// when none arrives, until fn or listening fails. Changes held back by a transaction that was still
+// running are not notified when it ends, so poll bounds how long they wait.
+func (w *WebhookRepository) ListenCarEvents(ctx context.Context, poll time.Duration, fn func() error) error {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.ListenCarEvents")
+
+	pooled, err := w.db.Acquire(ctx)
+	if err != nil {
+		span.End()
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+
+	// a listening connection must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+carEventsChannel)
+	span.End()
+
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	for {
+		if err = fn(); err != nil {
+			return err
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, poll)
+		_, err = conn.WaitForNotification(waitCtx)
+		cancel()
+
+		if err != nil && !(errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil) {
+			return err
+		}
+	}
+}
+
+// FanOutCarEvents turns up to limit changes recorded in the car audit after the cursor into events
+// and queues a delivery for every active webhook subscribed to them, then moves the cursor past
+// them, all in one transaction. Events without subscribers are not stored. Changes are read once
+// their transaction and all transactions started before it ended, so none is passed over. It
+// returns the number of changes read and of queued deliveries, zero while another instance holds
+// the cursor.
+func (w *WebhookRepository) FanOutCarEvents(ctx context.Context, limit int) (int, int, error) {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.FanOutCarEvents")
+	defer span.End()
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error begin transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	var (
+		cursorTx int64
+		cursorID int
+	)
+
+	err = tx.QueryRow(ctx, "SELECT tx_id::text::bigint, audit_id FROM webhook_cursor FOR UPDATE SKIP LOCKED").
+		Scan(&cursorTx, &cursorID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, nil
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	q := `SELECT id, tx_id::text::bigint, car_id, COALESCE(actor, ''), operation, diff, created_at
+			FROM car_audit
+			WHERE (tx_id, id) > ($1::text::xid8, $2) AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+			ORDER BY tx_id, id
+			LIMIT $3`
+
+	rows, err := tx.Query(ctx, q, strconv.FormatInt(cursorTx, 10), cursorID, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		auditIDs, carIDs    []int
+		types, actors, data []string
+		createdAt           []time.Time
+		read                int
+	)
+
+	for rows.Next() {
+		var (
+			operation string
+			event     models.WebhookEvent
+		)
+
+		err = rows.Scan(&cursorID, &cursorTx, &event.CarID, &event.Actor, &operation, &event.Data, &event.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+
+		read++
+
+		eventType, ok := carEventTypes[operation]
+		if !ok {
+			continue
+		}
+
+		auditIDs = append(auditIDs, cursorID)
+		types = append(types, eventType)
+		carIDs = append(carIDs, event.CarID)
+		actors = append(actors, event.Actor)
+		data = append(data, string(event.Data))
+		createdAt = append(createdAt, event.CreatedAt)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	if read == 0 {
+		return 0, 0, nil
+	}
+
+	q = `WITH events AS (
+				INSERT INTO webhook_events (audit_id, type, car_id, actor, data, created_at)
+				SELECT t.audit_id, t.type, t.car_id, NULLIF(t.actor, ''), t.data::jsonb, t.created_at
+				FROM unnest($1::bigint[], $2::text[], $3::int[], $4::text[], $5::text[], $6::timestamp[])
+					AS t(audit_id, type, car_id, actor, data, created_at)
+				WHERE EXISTS(SELECT 1 FROM webhooks w WHERE w.active AND t.type = ANY (w.events))
+				RETURNING id, type)
+			INSERT INTO webhook_deliveries (webhook_id, event_id)
+			SELECT w.id, e.id FROM events e JOIN webhooks w ON w.active AND e.type = ANY (w.events)`
+
+	tag, err := tx.Exec(ctx, q, auditIDs, types, carIDs, actors, data, createdAt)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE webhook_cursor SET tx_id = $1::text::xid8, audit_id = $2",
+		strconv.FormatInt(cursorTx, 10), cursorID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return read, int(tag.RowsAffected()), tx.Commit(ctx)
+}
+
+// ClaimDelivery locks the pending delivery due first, or one whose lease has expired, for lease
+// duration and counts the attempt. Deliveries of inactive webhooks wait until they are active again.
+// pgx.ErrNoRows is returned when there is nothing to do.
+func (w *WebhookRepository) ClaimDelivery(ctx context.Context, lease time.Duration) (models.DeliveryTask, error) {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.ClaimDelivery")
+	defer span.End()
+
+	q := `UPDATE webhook_deliveries d
+			SET attempts = d.attempts + 1,
+				locked_until = NOW() AT TIME ZONE 'utc' + make_interval(secs => $1)
+			FROM webhooks w, webhook_events e
+			WHERE d.id = (SELECT p.id
+						FROM webhook_deliveries p
+						JOIN webhooks pw ON pw.id = p.webhook_id
+						WHERE p.status = 'pending'
+						  AND pw.active
+						  AND p.next_attempt_at <= NOW() AT TIME ZONE 'utc'
+						  AND (p.locked_until IS NULL OR p.locked_until < NOW() AT TIME ZONE 'utc')
+						ORDER BY p.next_attempt_at, p.id
+						LIMIT 1 FOR UPDATE OF p SKIP LOCKED)
+			  AND w.id = d.webhook_id
+			  AND e.id = d.event_id
+			RETURNING d.id, d.attempts, w.url, w.secret, e.id, e.type, e.car_id, COALESCE(e.actor, ''), e.data, e.created_at`
+
+	var task models.DeliveryTask
+
+	err := w.db.QueryRow(ctx, q, lease.Seconds()).Scan(&task.DeliveryID, &task.Attempts, &task.URL, &task.Secret,
+		&task.Event.ID, &task.Event.Type, &task.Event.CarID, &task.Event.Actor, &task.Event.Data, &task.Event.CreatedAt)
+	if err != nil {
+		return models.DeliveryTask{}, err
+	}
+
+	return task, nil
+}
+
+// CompleteDelivery records the delivered attempt.
+func (w *WebhookRepository) CompleteDelivery(ctx context.Context, deliveryID, attempt int, responseStatus int) error {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.CompleteDelivery")
+	defer span.End()
+
+	q := `UPDATE webhook_deliveries
+			SET status = 'delivered', response_status = $1, error = NULL, locked_until = NULL,
+				delivered_at = NOW() AT TIME ZONE 'utc'
+			WHERE id = $2 AND attempts = $3 AND status = 'pending'`
+
+	return w.finish(ctx, q, responseStatus, deliveryID, attempt)
+}
+
+// RetryDelivery records a failed attempt and schedules the next one after delay.
+func (w *WebhookRepository) RetryDelivery(ctx context.Context, deliveryID, attempt int, responseStatus *int, errMsg string,
+	delay time.Duration) error {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.RetryDelivery")
+	defer span.End()
+
+	q := `UPDATE webhook_deliveries
+			SET response_status = $1, error = NULLIF($2, ''), locked_until = NULL,
+				next_attempt_at = NOW() AT TIME ZONE 'utc' + make_interval(secs => $3)
+			WHERE id = $4 AND attempts = $5 AND status = 'pending'`
+
+	return w.finish(ctx, q, responseStatus, errMsg, delay.Seconds(), deliveryID, attempt)
+}
+
+// DeadLetterDelivery records the last failed attempt, the delivery is not tried again.
+func (w *WebhookRepository) DeadLetterDelivery(ctx context.Context, deliveryID, attempt int, responseStatus *int,
+	errMsg string) error {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.DeadLetterDelivery")
+	defer span.End()
+
+	q := `UPDATE webhook_deliveries
+			SET status = 'dead', response_status = $1, error = NULLIF($2, ''), locked_until = NULL
+			WHERE id = $3 AND attempts = $4 AND status = 'pending'`
+
+	return w.finish(ctx, q, responseStatus, errMsg, deliveryID, attempt)
+}
+
+// ReleaseDelivery makes an interrupted delivery due again without counting the attempt.
+func (w *WebhookRepository) ReleaseDelivery(ctx context.Context, deliveryID, attempt int) error {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.ReleaseDelivery")
+	defer span.End()
+
+	q := `UPDATE webhook_deliveries SET attempts = attempts - 1, locked_until = NULL
+			WHERE id = $1 AND attempts = $2 AND status = 'pending'`
+
+	return w.finish(ctx, q, deliveryID, attempt)
+}
+
+// finish runs a write of a claimed delivery. The write is fenced by the attempt it was claimed
+// with, pgx.ErrNoRows is returned when the lease expired and another worker claimed it since.
+func (w *WebhookRepository) finish(ctx context.Context, q string, args ...any) error {
+	tag, err := w.db.Exec(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the delivery log of the webhook, latest first.
+func (w *WebhookRepository) GetDeliveries(ctx context.Context, webhookID int,
+	input domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error) {
+	ctx, span := w.tracer.Start(ctx, "webhookRepository.GetDeliveries")
+	defer span.End()
+
+	// a cursor is only valid for the webhook and status it was created with
+	plan, err := w.deliveryKeyset.Plan(pagination.Request{
+		Cursor: input.Cursor,
+		Limit:  input.Limit,
+		Filter: []any{webhookID, input.Status},
+	})
+	if err != nil {
+		return models.WebhookDeliveryList{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	var exists bool
+
+	if err = w.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM webhooks WHERE id = $1)", webhookID).Scan(&exists); err != nil {
+		return models.WebhookDeliveryList{}, err
+	}
+
+	if !exists {
+		return models.WebhookDeliveryList{}, pgx.ErrNoRows
+	}
+
+	query := plan.Apply(sq.Select("d.id, d.webhook_id, d.event_id, e.type, d.status, d.attempts, d.response_status",
+		"COALESCE(d.error, '')", "CASE WHEN d.status = 'pending' THEN d.next_attempt_at END", "d.created_at", "d.delivered_at").
+		From("webhook_deliveries d").
+		Join("webhook_events e ON e.id = d.event_id").
+		Where(sq.Eq{"d.webhook_id": webhookID}))
+
+	if input.Status != "" {
+		query = query.Where(sq.Eq{"d.status": input.Status})
+	}
+
+	sql, args, err := query.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return models.WebhookDeliveryList{}, err
+	}
+
+	rows, err := w.db.Query(ctx, sql, args...)
+	if err != nil {
+		return models.WebhookDeliveryList{}, err
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+
+		err = rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Status,
+			&delivery.Attempts, &delivery.ResponseStatus, &delivery.Error, &delivery.NextAttemptAt, &delivery.CreatedAt,
+			&delivery.DeliveredAt)
+		if err != nil {
+			return models.WebhookDeliveryList{}, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.WebhookDeliveryList{}, err
+	}
+
+	deliveries, page, err := plan.Page(deliveries)
+	if err != nil {
+		return models.WebhookDeliveryList{}, err
+	}
+
+	return models.WebhookDeliveryList{
+		NextCursor: page.Next,
+		PrevCursor: page.Prev,
+		HasMore:    page.HasMore,
+		Deliveries: deliveries,
+	}, nil
+}
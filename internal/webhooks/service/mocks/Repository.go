@@ -0,0 +1,344 @@
+// Code generated by mockery v2.42.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/Verce11o/effective-mobile-test/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/Verce11o/effective-mobile-test/internal/models"
+
+	time "time"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// ClaimDelivery provides a mock function with given fields: ctx, lease
+func (_m *Repository) ClaimDelivery(ctx context.Context, lease time.Duration) (models.DeliveryTask, error) {
+	ret := _m.Called(ctx, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDelivery")
+	}
+
+	var r0 models.DeliveryTask
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) (models.DeliveryTask, error)); ok {
+		return rf(ctx, lease)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) models.DeliveryTask); ok {
+		r0 = rf(ctx, lease)
+	} else {
+		r0 = ret.Get(0).(models.DeliveryTask)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteDelivery provides a mock function with given fields: ctx, deliveryID, attempt, responseStatus
+func (_m *Repository) CompleteDelivery(ctx context.Context, deliveryID int, attempt int, responseStatus int) error {
+	ret := _m.Called(ctx, deliveryID, attempt, responseStatus)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
+		r0 = rf(ctx, deliveryID, attempt, responseStatus)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, input
+func (_m *Repository) CreateWebhook(ctx context.Context, input domain.CreateWebhookRequest) (models.Webhook, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 models.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateWebhookRequest) (models.Webhook, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateWebhookRequest) models.Webhook); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateWebhookRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeadLetterDelivery provides a mock function with given fields: ctx, deliveryID, attempt, responseStatus, errMsg
+func (_m *Repository) DeadLetterDelivery(ctx context.Context, deliveryID int, attempt int, responseStatus *int, errMsg string) error {
+	ret := _m.Called(ctx, deliveryID, attempt, responseStatus, errMsg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeadLetterDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *int, string) error); ok {
+		r0 = rf(ctx, deliveryID, attempt, responseStatus, errMsg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWebhook provides a mock function with given fields: ctx, webhookID
+func (_m *Repository) DeleteWebhook(ctx context.Context, webhookID int) error {
+	ret := _m.Called(ctx, webhookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FanOutCarEvents provides a mock function with given fields: ctx, limit
+func (_m *Repository) FanOutCarEvents(ctx context.Context, limit int) (int, int, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FanOutCarEvents")
+	}
+
+	var r0 int
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, int, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) int); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int) error); ok {
+		r2 = rf(ctx, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDeliveries provides a mock function with given fields: ctx, webhookID, input
+func (_m *Repository) GetDeliveries(ctx context.Context, webhookID int, input domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error) {
+	ret := _m.Called(ctx, webhookID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeliveries")
+	}
+
+	var r0 models.WebhookDeliveryList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error)); ok {
+		return rf(ctx, webhookID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.GetWebhookDeliveriesRequest) models.WebhookDeliveryList); ok {
+		r0 = rf(ctx, webhookID, input)
+	} else {
+		r0 = ret.Get(0).(models.WebhookDeliveryList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.GetWebhookDeliveriesRequest) error); ok {
+		r1 = rf(ctx, webhookID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhook provides a mock function with given fields: ctx, webhookID
+func (_m *Repository) GetWebhook(ctx context.Context, webhookID int) (models.Webhook, error) {
+	ret := _m.Called(ctx, webhookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhook")
+	}
+
+	var r0 models.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Webhook, error)); ok {
+		return rf(ctx, webhookID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Webhook); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, webhookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhooks provides a mock function with given fields: ctx, input
+func (_m *Repository) GetWebhooks(ctx context.Context, input domain.GetWebhooksRequest) (models.WebhookList, error) {
+	ret := _m.Called(ctx, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhooks")
+	}
+
+	var r0 models.WebhookList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetWebhooksRequest) (models.WebhookList, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.GetWebhooksRequest) models.WebhookList); ok {
+		r0 = rf(ctx, input)
+	} else {
+		r0 = ret.Get(0).(models.WebhookList)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.GetWebhooksRequest) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListenCarEvents provides a mock function with given fields: ctx, poll, fn
+func (_m *Repository) ListenCarEvents(ctx context.Context, poll time.Duration, fn func() error) error {
+	ret := _m.Called(ctx, poll, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListenCarEvents")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration, func() error) error); ok {
+		r0 = rf(ctx, poll, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseDelivery provides a mock function with given fields: ctx, deliveryID, attempt
+func (_m *Repository) ReleaseDelivery(ctx context.Context, deliveryID int, attempt int) error {
+	ret := _m.Called(ctx, deliveryID, attempt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, deliveryID, attempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetryDelivery provides a mock function with given fields: ctx, deliveryID, attempt, responseStatus, errMsg, delay
+func (_m *Repository) RetryDelivery(ctx context.Context, deliveryID int, attempt int, responseStatus *int, errMsg string, delay time.Duration) error {
+	ret := _m.Called(ctx, deliveryID, attempt, responseStatus, errMsg, delay)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, *int, string, time.Duration) error); ok {
+		r0 = rf(ctx, deliveryID, attempt, responseStatus, errMsg, delay)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWebhook provides a mock function with given fields: ctx, webhookID, input
+func (_m *Repository) UpdateWebhook(ctx context.Context, webhookID int, input domain.UpdateWebhookRequest) (models.Webhook, error) {
+	ret := _m.Called(ctx, webhookID, input)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebhook")
+	}
+
+	var r0 models.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateWebhookRequest) (models.Webhook, error)); ok {
+		return rf(ctx, webhookID, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, domain.UpdateWebhookRequest) models.Webhook); ok {
+		r0 = rf(ctx, webhookID, input)
+	} else {
+		r0 = ret.Get(0).(models.Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, domain.UpdateWebhookRequest) error); ok {
+		r1 = rf(ctx, webhookID, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
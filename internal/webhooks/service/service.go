@@ -0,0 +1,497 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SignatureHeader carries "t=<unix time>,v1=<hex hmac>", the hmac-sha256 with the secret of
+	// the webhook over "<unix time>.<body>". Receivers should reject old timestamps to prevent replays.
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	// maxErrorLength limits the error of an attempt kept in the delivery log.
+	maxErrorLength = 1000
+	// maxResponseBody is the part of a failed response kept in the delivery log.
+	maxResponseBody = 256
+	// fanOutBatch is the number of car changes turned into events at once.
+	fanOutBatch = 500
+)
+
+// errLeaseLost stops a worker whose lease expired, the worker that claimed the delivery since carries on.
+var errLeaseLost = errors.New("delivery was claimed by another worker")
+
+//go:generate go run github.com/vektra/mockery/v2@v2.42.2 --name=Repository
+type Repository interface {
+	CreateWebhook(ctx context.Context, input domain.CreateWebhookRequest) (models.Webhook, error)
+	GetWebhook(ctx context.Context, webhookID int) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, input domain.GetWebhooksRequest) (models.WebhookList, error)
+	UpdateWebhook(ctx context.Context, webhookID int, input domain.UpdateWebhookRequest) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID int) error
+	FanOutCarEvents(ctx context.Context, limit int) (int, int, error)
+	ListenCarEvents(ctx context.Context, poll time.Duration, fn func() error) error
+	ClaimDelivery(ctx context.Context, lease time.Duration) (models.DeliveryTask, error)
+	CompleteDelivery(ctx context.Context, deliveryID, attempt int, responseStatus int) error
+	RetryDelivery(ctx context.Context, deliveryID, attempt int, responseStatus *int, errMsg string, delay time.Duration) error
+	DeadLetterDelivery(ctx context.Context, deliveryID, attempt int, responseStatus *int, errMsg string) error
+	ReleaseDelivery(ctx context.Context, deliveryID, attempt int) error
+	GetDeliveries(ctx context.Context, webhookID int, input domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error)
+}
+
+// Options configures delivery workers.
+type Options struct {
+	// Workers is the number of deliveries sent at the same time.
+	Workers int
+	// PollInterval is the pause of an idle worker before it looks for a due delivery again. Car
+	// changes are fanned out when they are notified, PollInterval is also the longest a change
+	// waits when its notification is missed or held back by an older running transaction.
+	PollInterval time.Duration
+	// Timeout limits one attempt, Lease must exceed it.
+	Timeout time.Duration
+	// Lease is how long a delivery stays locked by a worker before others consider it abandoned.
+	Lease time.Duration
+	// MaxAttempts is the number of attempts before a delivery is dead-lettered.
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+type Service struct {
+	log    *zap.SugaredLogger
+	repo   Repository
+	client *http.Client
+	tracer trace.Tracer
+	opts   Options
+}
+
+func NewService(log *zap.SugaredLogger, repo Repository, tracer trace.Tracer, opts Options) *Service {
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		// a redirect is a failed attempt, receivers must answer on the registered url
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &Service{log: log, repo: repo, client: client, tracer: tracer, opts: opts}
+}
+
+// CreateWebhook returns the webhook with its secret, a random one when input has none.
+func (s *Service) CreateWebhook(ctx context.Context, input domain.CreateWebhookRequest) (models.Webhook, error) {
+	ctx, span := s.tracer.Start(ctx, "webhookService.CreateWebhook")
+	defer span.End()
+
+	if err := validateTargetURL(input.URL); err != nil {
+		return models.Webhook{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	if input.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return models.Webhook{}, fmt.Errorf("generate secret: %w", err)
+		}
+
+		input.Secret = secret
+	}
+
+	webhook, err := s.repo.CreateWebhook(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot create webhook: %v", err)
+		return models.Webhook{}, fmt.Errorf("create webhook: %w", err)
+	}
+
+	webhook.Secret = input.Secret
+
+	return webhook, nil
+}
+
+func (s *Service) GetWebhook(ctx context.Context, webhookID int) (models.Webhook, error) {
+	ctx, span := s.tracer.Start(ctx, "webhookService.GetWebhook")
+	defer span.End()
+
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get webhook: %v", err)
+		return models.Webhook{}, fmt.Errorf("get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) GetWebhooks(ctx context.Context, input domain.GetWebhooksRequest) (models.WebhookList, error) {
+	ctx, span := s.tracer.Start(ctx, "webhookService.GetWebhooks")
+	defer span.End()
+
+	webhooks, err := s.repo.GetWebhooks(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get webhooks: %v", err)
+		return models.WebhookList{}, fmt.Errorf("get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *Service) UpdateWebhook(ctx context.Context, webhookID int, input domain.UpdateWebhookRequest) (models.Webhook, error) {
+	ctx, span := s.tracer.Start(ctx, "webhookService.UpdateWebhook")
+	defer span.End()
+
+	if err := validateTargetURL(input.URL); err != nil {
+		return models.Webhook{}, fmt.Errorf("%w: %w", response.ErrInvalidRequest, err)
+	}
+
+	webhook, err := s.repo.UpdateWebhook(ctx, webhookID, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot update webhook: %v", err)
+		return models.Webhook{}, fmt.Errorf("update webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, webhookID int) error {
+	ctx, span := s.tracer.Start(ctx, "webhookService.DeleteWebhook")
+	defer span.End()
+
+	if err := s.repo.DeleteWebhook(ctx, webhookID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot delete webhook: %v", err)
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetDeliveries(ctx context.Context, webhookID int, input domain.GetWebhookDeliveriesRequest) (models.WebhookDeliveryList, error) {
+	ctx, span := s.tracer.Start(ctx, "webhookService.GetDeliveries")
+	defer span.End()
+
+	deliveries, err := s.repo.GetDeliveries(ctx, webhookID, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		s.log.Infof("cannot get webhook deliveries: %v", err)
+		return models.WebhookDeliveryList{}, fmt.Errorf("get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Run queues deliveries of car changes and sends due deliveries until ctx is cancelled.
+// Interrupted deliveries are sent again later.
+func (s *Service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.fanOut(ctx)
+	}()
+
+	for i := 0; i < max(s.opts.Workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// fanOut queues deliveries of changes recorded in the car audit, so that no change saved is
+// missed by webhooks. Changes are picked up when the car audit notifies them.
+func (s *Service) fanOut(ctx context.Context) {
+	for {
+		err := s.repo.ListenCarEvents(ctx, s.opts.PollInterval, func() error {
+			s.fanOutAll(ctx)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.log.Infof("cannot listen for car events: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// fanOutAll queues deliveries until there is no full batch of changes left.
+func (s *Service) fanOutAll(ctx context.Context) {
+	for {
+		read, err := s.fanOutNext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Infof("cannot fan out car events: %v", err)
+			}
+
+			return
+		}
+
+		if read < fanOutBatch {
+			return
+		}
+	}
+}
+
+// fanOutNext queues deliveries of the next batch of changes and returns the number of changes read.
+func (s *Service) fanOutNext(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "webhookService.fanOutNext")
+	defer span.End()
+
+	read, queued, err := s.repo.FanOutCarEvents(ctx, fanOutBatch)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return 0, fmt.Errorf("fan out car events: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("webhook.events", read), attribute.Int("webhook.deliveries", queued))
+
+	return read, nil
+}
+
+func (s *Service) work(ctx context.Context) {
+	for {
+		found, err := s.processNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Infof("cannot process webhook delivery: %v", err)
+		}
+
+		if found && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// processNext claims one delivery and sends it, found is false when none is due.
+func (s *Service) processNext(ctx context.Context) (found bool, err error) {
+	task, err := s.repo.ClaimDelivery(ctx, s.opts.Lease)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("claim delivery: %w", err)
+	}
+
+	return true, s.deliver(ctx, task)
+}
+
+func (s *Service) deliver(ctx context.Context, task models.DeliveryTask) error {
+	ctx, span := s.tracer.Start(ctx, "webhookService.deliver")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("delivery.id", task.DeliveryID), attribute.Int("delivery.attempt", task.Attempts))
+
+	// a lease that expired while sending counts the attempt again, the delivery may have reached the receiver though
+	if s.opts.MaxAttempts > 0 && task.Attempts > s.opts.MaxAttempts {
+		if err := s.repo.DeadLetterDelivery(ctx, task.DeliveryID, task.Attempts, nil, "too many attempts"); err != nil {
+			return fmt.Errorf("dead-letter delivery: %w", fenced(err))
+		}
+
+		return nil
+	}
+
+	status, err := s.send(ctx, task)
+
+	if ctx.Err() != nil {
+		// the delivery is released without the cancelled ctx, otherwise it waits for the lease
+		if err = s.repo.ReleaseDelivery(context.WithoutCancel(ctx), task.DeliveryID, task.Attempts); err != nil {
+			return fmt.Errorf("release delivery: %w", fenced(err))
+		}
+
+		return ctx.Err()
+	}
+
+	if err == nil {
+		if err = s.repo.CompleteDelivery(ctx, task.DeliveryID, task.Attempts, status); err != nil {
+			return fmt.Errorf("complete delivery: %w", fenced(err))
+		}
+
+		return nil
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	var responseStatus *int
+	if status != 0 {
+		responseStatus = &status
+	}
+
+	errMsg := truncate(err.Error(), maxErrorLength)
+
+	if s.opts.MaxAttempts > 0 && task.Attempts >= s.opts.MaxAttempts {
+		s.log.Infof("webhook delivery %v is dead after %v attempts: %v", task.DeliveryID, task.Attempts, err)
+
+		if err = s.repo.DeadLetterDelivery(ctx, task.DeliveryID, task.Attempts, responseStatus, errMsg); err != nil {
+			return fmt.Errorf("dead-letter delivery: %w", fenced(err))
+		}
+
+		return nil
+	}
+
+	if err = s.repo.RetryDelivery(ctx, task.DeliveryID, task.Attempts, responseStatus, errMsg, s.backoff(task.Attempts)); err != nil {
+		return fmt.Errorf("retry delivery: %w", fenced(err))
+	}
+
+	return nil
+}
+
+// fenced reports a write that found the delivery claimed by another worker as errLeaseLost.
+func fenced(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errLeaseLost
+	}
+
+	return err
+}
+
+// send posts the signed event and returns the response status, zero when there is none.
+// Any status but 2xx fails the attempt.
+func (s *Service) send(ctx context.Context, task models.DeliveryTask) (int, error) {
+	body, err := json.Marshal(task.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, task.Event.Type)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(task.DeliveryID))
+	req.Header.Set(SignatureHeader, sign(task.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, snippet)
+}
+
+// backoff doubles the delay with every attempt up to RetryMaxDelay, half of it is random
+// so that deliveries failed together do not hit the receiver together again.
+func (s *Service) backoff(attempt int) time.Duration {
+	delay := s.opts.RetryMaxDelay
+	if shift := attempt - 1; shift < 32 {
+		delay = min(s.opts.RetryBaseDelay<<shift, s.opts.RetryMaxDelay)
+	}
+
+	if half := delay / 2; half > 0 {
+		return half + mathrand.N(half)
+	}
+
+	return delay
+}
+
+// sign returns the value of SignatureHeader for body sent at t.
+func sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func validateTargetURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https")
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("url has no host")
+	}
+
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	// the cut may split a character, postgres rejects invalid utf-8
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/Verce11o/effective-mobile-test/internal/domain"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/logger"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/response"
+	"github.com/Verce11o/effective-mobile-test/internal/lib/tracer"
+	"github.com/Verce11o/effective-mobile-test/internal/models"
+	repoMock "github.com/Verce11o/effective-mobile-test/internal/webhooks/service/mocks"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef"
+
+// verifySignature checks the signature header the way a receiver would.
+func verifySignature(t *testing.T, r *http.Request, body []byte) {
+	parts := strings.Split(r.Header.Get(SignatureHeader), ",")
+	require.Len(t, parts, 2)
+
+	timestamp, ok := strings.CutPrefix(parts[0], "t=")
+	require.True(t, ok)
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), time.Unix(unix, 0), time.Minute)
+
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+
+	require.Equal(t, "v1="+hex.EncodeToString(mac.Sum(nil)), parts[1])
+}
+
+func TestService_Deliver(t *testing.T) {
+	status := func(code int) *int { return &code }
+
+	tests := []struct {
+		name     string
+		attempts int
+		handler  http.HandlerFunc
+		// closed sends to a receiver that is not listening
+		closed    bool
+		setupRepo func(repo *repoMock.Repository)
+		wantErr   error
+	}{
+		{
+			name:     "delivered",
+			attempts: 1,
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			setupRepo: func(repo *repoMock.Repository) {
+				repo.On("CompleteDelivery", mock.Anything, 5, 1, http.StatusNoContent).Return(nil).Once()
+			},
+		},
+		{
+			name:     "receiver error is retried",
+			attempts: 2,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("boom"))
+			},
+			setupRepo: func(repo *repoMock.Repository) {
+				// the second retry waits between half and all of twice the base delay
+				repo.On("RetryDelivery", mock.Anything, 5, 2, status(http.StatusInternalServerError), "unexpected status 500: boom",
+					mock.MatchedBy(func(delay time.Duration) bool {
+						return delay >= time.Second && delay < 2*time.Second
+					})).Return(nil).Once()
+			},
+		},
+		{
+			name:     "redirect is a failure",
+			attempts: 1,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/elsewhere", http.StatusFound)
+			},
+			setupRepo: func(repo *repoMock.Repository) {
+				repo.On("RetryDelivery", mock.Anything, 5, 1, status(http.StatusFound), mock.Anything, mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			name:     "unreachable receiver",
+			attempts: 1,
+			closed:   true,
+			setupRepo: func(repo *repoMock.Repository) {
+				repo.On("RetryDelivery", mock.Anything, 5, 1, (*int)(nil), mock.AnythingOfType("string"), mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			name:     "last attempt is dead-lettered",
+			attempts: 3,
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			setupRepo: func(repo *repoMock.Repository) {
+				repo.On("DeadLetterDelivery", mock.Anything, 5, 3, status(http.StatusBadGateway), "unexpected status 502: ").
+					Return(nil).Once()
+			},
+		},
+		{
+			name:     "abandoned too often",
+			attempts: 4,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				t.Error("delivery was sent")
+			},
+			setupRepo: func(repo *repoMock.Repository) {
+				repo.On("DeadLetterDelivery", mock.Anything, 5, 4, (*int)(nil), "too many attempts").Return(nil).Once()
+			},
+		},
+		{
+			name:     "lease lost while sending",
+			attempts: 1,
+			handler:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			setupRepo: func(repo *repoMock.Repository) {
+				// another worker claimed the delivery since, its result is kept
+				repo.On("CompleteDelivery", mock.Anything, 5, 1, http.StatusNoContent).Return(pgx.ErrNoRows).Once()
+			},
+			wantErr: errLeaseLost,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			event := models.WebhookEvent{
+				ID:        9,
+				Type:      models.WebhookEventCarCreated,
+				CarID:     1,
+				Data:      json.RawMessage(`{"id":1,"regNum":"X123XX150"}`),
+				CreatedAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				verifySignature(t, r, body)
+				require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				require.Equal(t, models.WebhookEventCarCreated, r.Header.Get(EventHeader))
+				require.Equal(t, "5", r.Header.Get(DeliveryHeader))
+
+				var got models.WebhookEvent
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Equal(t, event, got)
+
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			if tt.closed {
+				server.Close()
+			}
+
+			repo := repoMock.NewRepository(t)
+			tt.setupRepo(repo)
+
+			s := NewService(logger.NewMockLogger(), repo, tracer.InitTracer(ctx, "", ""), Options{
+				Timeout:        time.Second,
+				MaxAttempts:    3,
+				RetryBaseDelay: time.Second,
+				RetryMaxDelay:  time.Minute,
+			})
+
+			err := s.deliver(ctx, models.DeliveryTask{
+				DeliveryID: 5,
+				Attempts:   tt.attempts,
+				URL:        server.URL,
+				Secret:     testSecret,
+				Event:      event,
+			})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_DeliverInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	repo := repoMock.NewRepository(t)
+	repo.On("ReleaseDelivery", mock.Anything, 5, 1).Return(nil).Once()
+
+	s := NewService(logger.NewMockLogger(), repo, tracer.InitTracer(ctx, "", ""), Options{Timeout: time.Second, MaxAttempts: 3})
+
+	err := s.deliver(ctx, models.DeliveryTask{DeliveryID: 5, Attempts: 1, URL: server.URL, Secret: testSecret})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestService_ProcessNextEmptyQueue(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repoMock.NewRepository(t)
+	repo.On("ClaimDelivery", mock.Anything, time.Minute).Return(models.DeliveryTask{}, pgx.ErrNoRows).Once()
+
+	s := NewService(logger.NewMockLogger(), repo, tracer.InitTracer(ctx, "", ""), Options{Lease: time.Minute})
+
+	found, err := s.processNext(ctx)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestService_CreateWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      domain.CreateWebhookRequest
+		wantSecret string
+		wantErr    error
+	}{
+		{
+			name:       "given secret",
+			input:      domain.CreateWebhookRequest{URL: "https://billing.example.com/hooks", Secret: testSecret},
+			wantSecret: testSecret,
+		},
+		{
+			name:  "generated secret",
+			input: domain.CreateWebhookRequest{URL: "http://localhost:8080/hooks"},
+		},
+		{
+			name:    "not http",
+			input:   domain.CreateWebhookRequest{URL: "ftp://billing.example.com/hooks"},
+			wantErr: response.ErrInvalidRequest,
+		},
+		{
+			name:    "no host",
+			input:   domain.CreateWebhookRequest{URL: "https:///hooks"},
+			wantErr: response.ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			repo := repoMock.NewRepository(t)
+
+			if tt.wantErr == nil {
+				repo.On("CreateWebhook", mock.Anything, mock.MatchedBy(func(input domain.CreateWebhookRequest) bool {
+					return input.URL == tt.input.URL && len(input.Secret) >= 16
+				})).Return(models.Webhook{ID: 1, URL: tt.input.URL}, nil).Once()
+			}
+
+			s := NewService(logger.NewMockLogger(), repo, tracer.InitTracer(ctx, "", ""), Options{})
+
+			webhook, err := s.CreateWebhook(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.wantSecret != "" {
+				require.Equal(t, tt.wantSecret, webhook.Secret)
+				return
+			}
+
+			require.True(t, strings.HasPrefix(webhook.Secret, "whsec_"))
+		})
+	}
+}
+
+func TestService_FanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := repoMock.NewRepository(t)
+
+	// listening is retried after a failure
+	repo.On("ListenCarEvents", mock.Anything, time.Millisecond, mock.Anything).
+		Return(errors.New("connection refused")).Once()
+
+	// a full batch is followed by the next one right away
+	repo.On("ListenCarEvents", mock.Anything, time.Millisecond, mock.Anything).
+		Run(func(args mock.Arguments) {
+			require.NoError(t, args.Get(2).(func() error)())
+			cancel()
+		}).Return(context.Canceled).Once()
+	repo.On("FanOutCarEvents", mock.Anything, fanOutBatch).Return(fanOutBatch, 3, nil).Once()
+	repo.On("FanOutCarEvents", mock.Anything, fanOutBatch).Return(2, 1, nil).Once()
+
+	s := NewService(logger.NewMockLogger(), repo, tracer.InitTracer(ctx, "", ""), Options{PollInterval: time.Millisecond})
+
+	s.fanOut(ctx)
+
+	repoErr := errors.New("connection refused")
+	repo.On("FanOutCarEvents", mock.Anything, fanOutBatch).Return(0, 0, repoErr).Once()
+
+	_, err := s.fanOutNext(context.Background())
+	require.ErrorIs(t, err, repoErr)
+}
+
+func TestService_Backoff(t *testing.T) {
+	s := &Service{opts: Options{RetryBaseDelay: time.Second, RetryMaxDelay: 10 * time.Second}}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		delay := s.backoff(tt.attempt)
+		require.GreaterOrEqual(t, delay, tt.want/2)
+		require.Less(t, delay, tt.want)
+	}
+}
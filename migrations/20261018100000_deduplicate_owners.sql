@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE cars
+SET ownerID = d.keep_id
+FROM (SELECT id, MIN(id) OVER (PARTITION BY name, surname, COALESCE(patronymic, '')) AS keep_id
+      FROM owners) d
+WHERE cars.ownerID = d.id
+  AND d.id <> d.keep_id;
+
+DELETE
+FROM owners o USING owners k
+WHERE o.name = k.name
+  AND o.surname = k.surname
+  AND COALESCE(o.patronymic, '') = COALESCE(k.patronymic, '')
+  AND o.id > k.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_owners_identity ON owners (name, surname, COALESCE(patronymic, ''));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_owners_identity;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ownership_history
+(
+    id                SERIAL PRIMARY KEY,
+    car_id            INT       NOT NULL,
+    previous_owner_id INT       NULL,
+    new_owner_id      INT       NOT NULL,
+    effective_at      TIMESTAMP NOT NULL,
+    created_at        TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'utc'),
+    FOREIGN KEY (car_id) REFERENCES cars (id) ON DELETE CASCADE,
+    FOREIGN KEY (previous_owner_id) REFERENCES owners (id),
+    FOREIGN KEY (new_owner_id) REFERENCES owners (id)
+);
+
+CREATE INDEX idx_ownership_history_car_effective_at ON ownership_history (car_id, effective_at);
+
+INSERT INTO ownership_history (car_id, previous_owner_id, new_owner_id, effective_at)
+SELECT id, NULL, ownerID, COALESCE(created_at, NOW() AT TIME ZONE 'utc')
+FROM cars
+WHERE ownerID IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ownership_history;
+-- +goose StatementEnd
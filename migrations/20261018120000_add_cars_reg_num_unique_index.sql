@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- repeated POST /cars requests could create the same plate several times,
+-- keep the oldest row of every plate before enforcing uniqueness
+DELETE
+FROM cars c USING cars k
+WHERE c.reg_num = k.reg_num
+  AND c.id > k.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cars_reg_num ON cars (reg_num);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_cars_reg_num;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS car_jobs
+(
+    id           SERIAL PRIMARY KEY,
+    status       VARCHAR(16) NOT NULL DEFAULT 'queued',
+    request      JSONB       NOT NULL,
+    total        INT         NOT NULL,
+    processed    INT         NOT NULL DEFAULT 0,
+    result       JSONB       NULL,
+    error        TEXT        NULL,
+    attempts     INT         NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP   NULL,
+    created_at   TIMESTAMP DEFAULT (NOW() AT TIME ZONE 'utc'),
+    started_at   TIMESTAMP   NULL,
+    finished_at  TIMESTAMP   NULL
+);
+
+CREATE INDEX idx_car_jobs_pending ON car_jobs (id) WHERE status IN ('queued', 'running');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE car_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE cars
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+
+-- plates of deleted cars can be registered again
+DROP INDEX IF EXISTS idx_cars_reg_num;
+CREATE UNIQUE INDEX idx_cars_reg_num ON cars (reg_num) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_cars_deleted_at ON cars (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE
+FROM cars
+WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_cars_deleted_at;
+DROP INDEX IF EXISTS idx_cars_reg_num;
+CREATE UNIQUE INDEX idx_cars_reg_num ON cars (reg_num);
+
+ALTER TABLE cars
+    DROP COLUMN deleted_at;
+-- +goose StatementEnd
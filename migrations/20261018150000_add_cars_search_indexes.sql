@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 'simple' configuration does not stem, plates and names are not words of any language
+ALTER TABLE cars
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        to_tsvector('simple', reg_num || ' ' || COALESCE(mark, '') || ' ' || COALESCE(model, ''))
+        ) STORED;
+
+ALTER TABLE owners
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        to_tsvector('simple', name || ' ' || surname || ' ' || COALESCE(patronymic, ''))
+        ) STORED;
+
+CREATE INDEX idx_cars_search_vector ON cars USING GIN (search_vector);
+CREATE INDEX idx_owners_search_vector ON owners USING GIN (search_vector);
+
+CREATE INDEX idx_cars_reg_num_trgm ON cars USING GIN (reg_num gin_trgm_ops);
+CREATE INDEX idx_cars_mark_trgm ON cars USING GIN (mark gin_trgm_ops);
+CREATE INDEX idx_cars_model_trgm ON cars USING GIN (model gin_trgm_ops);
+CREATE INDEX idx_owners_name_trgm ON owners USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_owners_surname_trgm ON owners USING GIN (surname gin_trgm_ops);
+CREATE INDEX idx_owners_patronymic_trgm ON owners USING GIN (patronymic gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_owners_patronymic_trgm;
+DROP INDEX IF EXISTS idx_owners_surname_trgm;
+DROP INDEX IF EXISTS idx_owners_name_trgm;
+DROP INDEX IF EXISTS idx_cars_model_trgm;
+DROP INDEX IF EXISTS idx_cars_mark_trgm;
+DROP INDEX IF EXISTS idx_cars_reg_num_trgm;
+DROP INDEX IF EXISTS idx_owners_search_vector;
+DROP INDEX IF EXISTS idx_cars_search_vector;
+
+ALTER TABLE owners
+    DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE cars
+    DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
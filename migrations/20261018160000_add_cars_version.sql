@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- version is incremented by every write of a car and checked by conditional updates
+ALTER TABLE cars
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE cars
+    DROP COLUMN version;
+-- +goose StatementEnd
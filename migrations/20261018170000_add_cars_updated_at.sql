@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- updated_at is set by every write of a car, it is the Last-Modified of car reads
+ALTER TABLE cars
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc');
+
+UPDATE cars
+SET updated_at = COALESCE(GREATEST(created_at, deleted_at), NOW() AT TIME ZONE 'utc');
+
+-- lists are as new as the latest change of any car
+CREATE INDEX idx_cars_updated_at ON cars (updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_cars_updated_at;
+
+ALTER TABLE cars
+    DROP COLUMN updated_at;
+-- +goose StatementEnd
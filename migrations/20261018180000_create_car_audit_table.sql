@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- entries outlive purged cars, so car_id has no foreign key
+CREATE TABLE IF NOT EXISTS car_audit
+(
+    id         BIGSERIAL PRIMARY KEY,
+    car_id     INT       NOT NULL,
+    actor      TEXT      NULL,
+    operation  TEXT      NOT NULL,
+    diff       JSONB     NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc')
+);
+
+CREATE INDEX idx_car_audit_car_id ON car_audit (car_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE car_audit;
+-- +goose StatementEnd
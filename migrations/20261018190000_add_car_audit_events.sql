@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- audit entries are the change feed, mark and owner of the car are kept to filter it
+ALTER TABLE car_audit
+    ADD COLUMN IF NOT EXISTS mark     TEXT NULL,
+    ADD COLUMN IF NOT EXISTS owner_id INT  NULL;
+
+UPDATE car_audit a
+SET mark     = c.mark,
+    owner_id = c.ownerid
+FROM cars c
+WHERE c.id = a.car_id;
+
+-- listeners read new entries by themselves, so one notification per statement is enough
+CREATE OR REPLACE FUNCTION notify_car_events() RETURNS trigger AS
+$$
+BEGIN
+    PERFORM pg_notify('car_events', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER car_audit_notify
+    AFTER INSERT
+    ON car_audit
+    FOR EACH STATEMENT
+EXECUTE FUNCTION notify_car_events();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS car_audit_notify ON car_audit;
+DROP FUNCTION IF EXISTS notify_car_events();
+
+ALTER TABLE car_audit
+    DROP COLUMN mark,
+    DROP COLUMN owner_id;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id         SERIAL PRIMARY KEY,
+    url        TEXT      NOT NULL,
+    events     TEXT[]    NOT NULL,
+    secret     TEXT      NOT NULL,
+    active     BOOLEAN   NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc')
+);
+
+CREATE TABLE IF NOT EXISTS webhook_events
+(
+    id         BIGSERIAL PRIMARY KEY,
+    type       TEXT      NOT NULL,
+    car_id     INT       NOT NULL,
+    actor      TEXT      NULL,
+    data       JSONB     NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc')
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              BIGSERIAL PRIMARY KEY,
+    webhook_id      INT       NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id        BIGINT    NOT NULL REFERENCES webhook_events (id) ON DELETE CASCADE,
+    status          TEXT      NOT NULL DEFAULT 'pending',
+    attempts        INT       NOT NULL DEFAULT 0,
+    response_status INT       NULL,
+    error           TEXT      NULL,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    locked_until    TIMESTAMP NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    delivered_at    TIMESTAMP NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+CREATE INDEX idx_webhook_deliveries_event ON webhook_deliveries (event_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_events;
+DROP TABLE webhooks;
+-- +goose StatementEnd
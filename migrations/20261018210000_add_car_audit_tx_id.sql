@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- entries of one transaction share tx_id. The change feed reads entries of finished transactions
+-- only, ordered by tx_id and id, so it never passes over an entry committed later.
+-- Existing entries get the id of this transaction.
+ALTER TABLE car_audit ADD COLUMN tx_id xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX idx_car_audit_tx_id ON car_audit (tx_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_car_audit_tx_id;
+
+ALTER TABLE car_audit DROP COLUMN tx_id;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- webhook_cursor is the position in car_audit up to which changes were fanned out to webhooks.
+-- Changes recorded before webhooks existed are not sent.
+CREATE TABLE IF NOT EXISTS webhook_cursor
+(
+    id       BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    tx_id    xid8   NOT NULL,
+    audit_id BIGINT NOT NULL
+);
+
+INSERT INTO webhook_cursor (tx_id, audit_id) VALUES (pg_snapshot_xmin(pg_current_snapshot()), 0);
+
+ALTER TABLE webhook_events ADD COLUMN audit_id BIGINT NULL UNIQUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhook_events DROP COLUMN audit_id;
+
+DROP TABLE webhook_cursor;
+-- +goose StatementEnd
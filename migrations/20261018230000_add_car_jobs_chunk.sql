@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- chunk_end is the end of the chunk being processed, so that a worker claiming an interrupted
+-- job creates the same chunk again and looks for cars the previous attempt created in it.
+ALTER TABLE car_jobs
+    ADD COLUMN chunk_end INT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE car_jobs
+    DROP COLUMN chunk_end;
+-- +goose StatementEnd
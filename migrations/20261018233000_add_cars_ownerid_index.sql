@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- search finds cars of matching owners by it
+CREATE INDEX IF NOT EXISTS idx_cars_ownerid ON cars (ownerid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_cars_ownerid;
+-- +goose StatementEnd